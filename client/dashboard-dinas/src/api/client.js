@@ -1,7 +1,11 @@
 import axios from 'axios';
 
+// W3C trace ID form (32 hex digits), so the services keep it as the
+// request's trace ID and it matches their logs and traces.
 const generateTraceId = () => {
-  return `admin-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`;
+  const bytes = new Uint8Array(16);
+  crypto.getRandomValues(bytes);
+  return Array.from(bytes, (b) => b.toString(16).padStart(2, '0')).join('');
 };
 
 const RETRY_CONFIG = {
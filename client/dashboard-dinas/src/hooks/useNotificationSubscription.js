@@ -27,25 +27,35 @@ export const useNotificationSubscriptionDashboard = (onNotification) => {
       token: token
     });
 
-    const url = `/api/notifications/subscribe?${params.toString()}`;
-
-    console.log('[DashboardNotification] Connecting to SSE:', url);
-
     let eventSource = null;
     let retryTimeout = null;
+    // Seq of the last event seen; the server replays from a little before it
+    // on reconnect, so events already shown are skipped by id.
+    let lastEventId = '';
+    const seenIds = new Set();
 
     const connect = () => {
       if (eventSource) {
         eventSource.close();
       }
 
+      if (lastEventId) params.set('last_event_id', lastEventId);
+      const url = `/api/notifications/subscribe?${params.toString()}`;
+      console.log('[DashboardNotification] Connecting to SSE:', url);
+
       eventSource = new EventSource(url);
 
       eventSource.onopen = () => {
         console.log('[DashboardNotification] SSE Connection established ✅');
       };
 
+      eventSource.addEventListener('token_expired', () => {
+        console.log('[DashboardNotification] Session expired, closing stream');
+        eventSource.close();
+      });
+
       eventSource.onmessage = (event) => {
+        if (event.lastEventId) lastEventId = event.lastEventId;
         try {
           const data = JSON.parse(event.data);
 
@@ -53,6 +63,10 @@ export const useNotificationSubscriptionDashboard = (onNotification) => {
             console.log('[DashboardNotification] Server confirmed connection 📡');
             return;
           }
+          if (data.id) {
+            if (seenIds.has(data.id)) return;
+            seenIds.add(data.id);
+          }
 
           console.log('[DashboardNotification] New Event:', data);
 
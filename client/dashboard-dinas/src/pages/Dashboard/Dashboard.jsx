@@ -45,6 +45,16 @@ const Dashboard = () => {
         message: event.message || 'Ada laporan baru masuk',
       });
     }
+
+    // New reports and escalations arrive batched per department.
+    if (event.type === 'admin_digest') {
+      loadReports();
+      notificationService.addNotification({
+        type: event.digest?.counts?.escalation ? 'warning' : 'info',
+        title: event.title || 'Ringkasan Laporan',
+        message: event.message || 'Ada laporan baru masuk',
+      });
+    }
   });
 
   // Department category mapping for access control
@@ -161,7 +171,7 @@ const Dashboard = () => {
       notificationService.addNotification({
         type: 'success',
         title: 'Laporan Diteruskan',
-        message: `Laporan berhasil diteruskan ke ${forwardModal.forwardTo}`,
+        message: `Laporan dalam antrean untuk diteruskan ke ${forwardModal.forwardTo}`,
       });
 
       setForwardModal({ show: false, reportId: null, forwardTo: '', notes: '' });
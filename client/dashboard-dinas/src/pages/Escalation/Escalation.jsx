@@ -82,6 +82,12 @@ const Escalation = () => {
     return { status: 'ok', label: `Sisa ${Math.round(hoursRemaining / 24)} hari` };
   };
 
+  const isNearSLA = (report) =>
+    !report.is_escalated &&
+    Array.isArray(report.sla_warnings) &&
+    report.sla_warnings.length > 0 &&
+    getSLAStatus(report).status !== 'breached';
+
   const getFilteredReports = () => {
     if (filter === 'all') return escalatedReports;
     if (filter === 'sla-breached') {
@@ -90,6 +96,9 @@ const Escalation = () => {
         return sla.status === 'breached';
       });
     }
+    if (filter === 'sla-warning') {
+      return escalatedReports.filter(isNearSLA);
+    }
     if (filter === 'escalated') {
       return escalatedReports.filter(r => r.is_escalated);
     }
@@ -98,6 +107,7 @@ const Escalation = () => {
 
   const filteredReports = getFilteredReports();
   const breachedCount = escalatedReports.filter(r => getSLAStatus(r).status === 'breached').length;
+  const nearSLACount = escalatedReports.filter(isNearSLA).length;
   const escalatedCount = escalatedReports.filter(r => r.is_escalated).length;
 
   return (
@@ -165,6 +175,12 @@ const Escalation = () => {
         >
           Melewati SLA ({breachedCount})
         </button>
+        <button
+          className={`filter-btn ${filter === 'sla-warning' ? 'filter-btn--active' : ''}`}
+          onClick={() => setFilter('sla-warning')}
+        >
+          Mendekati SLA ({nearSLACount})
+        </button>
         <button
           className={`filter-btn ${filter === 'escalated' ? 'filter-btn--active' : ''}`}
           onClick={() => setFilter('escalated')}
@@ -18,6 +18,9 @@ const normalizeStatus = (status) => {
   if (normalized === 'REJECTED' || normalized === 'DITOLAK') {
     return 'REJECTED';
   }
+  if (normalized === 'DISPATCHED' || normalized === 'DITERUSKAN') {
+    return 'DISPATCHED';
+  }
   if (normalized === 'PENDING' || normalized === 'MENUNGGU') {
     return 'PENDING';
   }
@@ -31,6 +34,10 @@ const StatusBadge = ({ status }) => {
       label: 'Menunggu',
       className: 'status-badge--pending'
     },
+    DISPATCHED: {
+      label: 'Diteruskan',
+      className: 'status-badge--dispatched'
+    },
     IN_PROGRESS: {
       label: 'Diproses',
       className: 'status-badge--in-progress'
@@ -34,27 +34,41 @@ export const useNotificationSubscription = () => {
       token: token
     });
 
-    const url = `/api/notifications/subscribe?${params.toString()}`;
-
-    console.log('[Notification] Connecting to SSE:', url);
-
     let eventSource = null;
     let retryTimeout = null;
+    // Seq of the last event seen; the server replays from a little before it
+    // on reconnect, so events already shown are skipped by id.
+    let lastEventId = '';
+    const seenIds = new Set();
 
     const connect = () => {
       if (eventSource) eventSource.close();
 
+      if (lastEventId) params.set('last_event_id', lastEventId);
+      const url = `/api/notifications/subscribe?${params.toString()}`;
+      console.log('[Notification] Connecting to SSE:', url);
+
       eventSource = new EventSource(url);
 
       eventSource.onopen = () => {
         console.log('[Notification] SSE Connection established ✅');
       };
 
+      eventSource.addEventListener('token_expired', () => {
+        console.log('[Notification] Session expired, closing stream');
+        eventSource.close();
+      });
+
       eventSource.onmessage = (event) => {
+        if (event.lastEventId) lastEventId = event.lastEventId;
         try {
           const data = JSON.parse(event.data);
           
           if (data.type === 'connected') return;
+          if (data.id) {
+            if (seenIds.has(data.id)) return;
+            seenIds.add(data.id);
+          }
 
           console.log('[Notification] Received event:', data);
 
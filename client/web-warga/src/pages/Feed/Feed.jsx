@@ -1,6 +1,8 @@
 import React, { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { reportService } from '../../services/reportService';
+import { announcementService } from '../../services/announcementService';
+import { authService } from '../../services/authService';
 import { useNotificationStore } from '../../store/notificationStore';
 import Card from '../../components/Card';
 import StatusBadge from '../../components/StatusBadge';
@@ -17,11 +19,26 @@ const Feed = () => {
   const [page, setPage] = useState(1);
   const [hasMore, setHasMore] = useState(true);
   const [upvotingIds, setUpvotingIds] = useState(new Set());
+  const [followingIds, setFollowingIds] = useState(new Set());
+  const [announcements, setAnnouncements] = useState([]);
 
   useEffect(() => {
     loadReports();
   }, [page]);
 
+  useEffect(() => {
+    loadAnnouncements();
+  }, []);
+
+  const loadAnnouncements = async () => {
+    try {
+      const profile = await authService.getProfile().catch(() => ({}));
+      setAnnouncements(await announcementService.getActiveAnnouncements(profile || {}));
+    } catch (error) {
+      console.error('[Feed] Error loading announcements:', error);
+    }
+  };
+
   useEffect(() => {
     if (!lastReportStatusUpdate?.reportId || !lastReportStatusUpdate?.status) return;
 
@@ -84,7 +101,14 @@ const Feed = () => {
       if (hasUpvoted) {
         await reportService.removeUpvote(reportId);
       } else {
-        await reportService.upvoteReport(reportId);
+        const result = await reportService.upvoteReport(reportId);
+        if (result?.is_following) {
+          setReports((prev) =>
+            prev.map((report) =>
+              report.id === reportId ? { ...report, isFollowing: true } : report
+            )
+          );
+        }
       }
 
       addNotification({
@@ -121,6 +145,48 @@ const Feed = () => {
     }
   };
 
+  const handleFollow = async (reportId, isFollowing) => {
+    if (followingIds.has(reportId)) return;
+
+    setFollowingIds((prev) => new Set([...prev, reportId]));
+
+    try {
+      if (isFollowing) {
+        await reportService.unfollowReport(reportId);
+      } else {
+        await reportService.followReport(reportId);
+      }
+
+      setReports((prev) =>
+        prev.map((report) =>
+          report.id === reportId ? { ...report, isFollowing: !isFollowing } : report
+        )
+      );
+
+      addNotification({
+        type: 'success',
+        title: isFollowing ? 'Berhenti Mengikuti' : 'Mengikuti Laporan',
+        message: isFollowing
+          ? 'Anda tidak akan menerima pembaruan laporan ini lagi'
+          : 'Anda akan menerima pembaruan saat status laporan berubah',
+      });
+    } catch (error) {
+      console.error('Error following report:', error);
+
+      addNotification({
+        type: 'error',
+        title: 'Gagal Memperbarui',
+        message: error.response?.data?.message || 'Terjadi kesalahan saat mengikuti laporan',
+      });
+    } finally {
+      setFollowingIds((prev) => {
+        const newSet = new Set(prev);
+        newSet.delete(reportId);
+        return newSet;
+      });
+    }
+  };
+
   const handleLoadMore = () => {
     setPage((prev) => prev + 1);
   };
@@ -139,6 +205,32 @@ const Feed = () => {
       </div>
 
       <div className="container">
+        {announcements.length > 0 && (
+          <div className="feed-announcements">
+            {announcements.map((announcement) => (
+              <div
+                key={announcement.id}
+                className={`announcement announcement--${announcement.severity}`}
+              >
+                <div className="announcement__header">
+                  <span className="announcement__category">{announcement.category}</span>
+                  <span className="announcement__until">
+                    Berlaku hingga{' '}
+                    {new Date(announcement.valid_until).toLocaleString('id-ID', {
+                      day: 'numeric',
+                      month: 'short',
+                      hour: '2-digit',
+                      minute: '2-digit',
+                    })}
+                  </span>
+                </div>
+                <h3 className="announcement__title">{announcement.title}</h3>
+                <p className="announcement__message">{announcement.message}</p>
+              </div>
+            ))}
+          </div>
+        )}
+
         {loading && page === 1 ? (
           <div className="feed-loading">
             <div className="spinner"></div>
@@ -160,6 +252,8 @@ const Feed = () => {
                 report={report}
                 onUpvote={handleUpvote}
                 isUpvoting={upvotingIds.has(report.id)}
+                onFollow={handleFollow}
+                isFollowPending={followingIds.has(report.id)}
               />
             ))}
 
@@ -182,7 +276,7 @@ const Feed = () => {
   );
 };
 
-const ReportCard = ({ report, onUpvote, isUpvoting }) => {
+const ReportCard = ({ report, onUpvote, isUpvoting, onFollow, isFollowPending }) => {
   const {
     id,
     title,
@@ -193,6 +287,7 @@ const ReportCard = ({ report, onUpvote, isUpvoting }) => {
     status,
     upvotes = 0,
     hasUpvoted = false,
+    isFollowing = false,
     authorName,
     reporterName,
     isAnonymous,
@@ -271,6 +366,13 @@ const ReportCard = ({ report, onUpvote, isUpvoting }) => {
             </span>
           )}
         </button>
+        <button
+          className={`follow-btn ${isFollowing ? 'follow-btn--active' : ''}`}
+          onClick={() => onFollow(id, isFollowing)}
+          disabled={isFollowPending}
+        >
+          {isFollowing ? '✓ Mengikuti' : '+ Ikuti'}
+        </button>
       </div>
     </Card>
   );
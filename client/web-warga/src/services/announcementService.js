@@ -0,0 +1,16 @@
+import api from '../api/client';
+
+export const announcementService = {
+  // Active announcements, narrowed to the home area when one is given.
+  getActiveAnnouncements: async (homeArea = {}) => {
+    const params = {};
+    if (homeArea.home_region) params.region = homeArea.home_region;
+    if (homeArea.home_lat != null && homeArea.home_lng != null) {
+      params.lat = homeArea.home_lat;
+      params.lng = homeArea.home_lng;
+    }
+    const response = await api.get('/notifications/announcements/active', { params });
+    const announcements = response.data.data?.announcements;
+    return Array.isArray(announcements) ? announcements : [];
+  },
+};
@@ -24,4 +24,9 @@ export const authService = {
     const response = await api.get('/auth/me');
     return response.data.data;
   },
+
+  updateHomeArea: async ({ region = '', lat = null, lng = null }) => {
+    const response = await api.put('/auth/me/home-area', { region, lat, lng });
+    return response.data.data;
+  },
 };
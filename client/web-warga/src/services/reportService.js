@@ -33,6 +33,8 @@ const normalizeReport = (report) => {
   return {
     ...report,
     status: normalizeStatus(report.status),
+    hasUpvoted: report.hasUpvoted ?? report.has_upvoted ?? false,
+    isFollowing: report.isFollowing ?? report.is_following ?? false,
   };
 };
 
@@ -77,6 +79,22 @@ export const reportService = {
     return response.data.data;
   },
 
+  followReport: async (reportId) => {
+    const response = await api.post(`/reports/${reportId}/follow`);
+    return response.data.data;
+  },
+
+  unfollowReport: async (reportId) => {
+    const response = await api.delete(`/reports/${reportId}/follow`);
+    return response.data.data;
+  },
+
+  getFollowedReports: async () => {
+    const response = await api.get('/reports/following');
+    const reports = response.data.data;
+    return Array.isArray(reports) ? reports.map(normalizeReport) : [];
+  },
+
   uploadImage: async (file) => {
     const formData = new FormData();
     formData.append('image', file);
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"citizen-reporting-system/pkg/telemetry"
+
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -13,7 +15,7 @@ func ConnectMongo(uri string, dbName string) (*mongo.Database, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	clientOptions := options.Client().ApplyURI(uri)
+	clientOptions := options.Client().ApplyURI(uri).SetMonitor(telemetry.MongoMonitor())
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
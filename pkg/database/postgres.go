@@ -5,6 +5,8 @@ import (
 	"log"
 	"time"
 
+	"citizen-reporting-system/pkg/telemetry"
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -31,6 +33,10 @@ func ConnectPostgres(dsn string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
 	}
 
+	if err := telemetry.InstrumentGorm(db); err != nil {
+		log.Printf("Failed to register tracing callbacks: %v", err)
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sql.DB from gorm: %w", err)
@@ -0,0 +1,198 @@
+// Package events defines the messages exchanged between services, wrapped in
+// CloudEvents 1.0 envelopes (JSON structured mode) with a schema version
+// extension so consumers can upcast old payloads and refuse newer ones.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	SpecVersion = "1.0"
+	ContentType = "application/cloudevents+json"
+
+	// AMQP header prefix from the CloudEvents AMQP binding. Structured-mode
+	// messages carry the full event in the body; the headers mirror the
+	// routing attributes so tooling can inspect them without parsing.
+	headerPrefix = "cloudEvents:"
+)
+
+var (
+	ErrUnknownType         = errors.New("unknown event type")
+	ErrUnsupportedSpec     = errors.New("unsupported CloudEvents specversion")
+	ErrIncompatibleVersion = errors.New("event schema version newer than supported")
+)
+
+type Envelope struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	DataSchema      string          `json:"dataschema,omitempty"`
+	SchemaVersion   int             `json:"schemaversion"`
+	Data            json.RawMessage `json:"data"`
+}
+
+func schemaURI(eventType string, version int) string {
+	return fmt.Sprintf("urn:citizen-reporting:schema:%s:v%d", eventType, version)
+}
+
+// New wraps data in an envelope at the type's current schema version.
+// source is the publishing service, e.g. "/report-service".
+func New(source, eventType, subject string, data interface{}) (Envelope, error) {
+	version, ok := currentVersions[eventType]
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s", ErrUnknownType, eventType)
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{
+		SpecVersion:     SpecVersion,
+		ID:              uuid.NewString(),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		DataSchema:      schemaURI(eventType, version),
+		SchemaVersion:   version,
+		Data:            raw,
+	}, nil
+}
+
+// Headers returns the AMQP application properties that accompany the
+// structured-mode body.
+func (e Envelope) Headers() map[string]string {
+	h := map[string]string{
+		headerPrefix + "specversion":   e.SpecVersion,
+		headerPrefix + "id":            e.ID,
+		headerPrefix + "source":        e.Source,
+		headerPrefix + "type":          e.Type,
+		headerPrefix + "schemaversion": strconv.Itoa(e.SchemaVersion),
+	}
+	if e.Subject != "" {
+		h[headerPrefix+"subject"] = e.Subject
+	}
+	return h
+}
+
+func (e Envelope) Publishing() (amqp.Publishing, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return amqp.Publishing{}, err
+	}
+	headers := amqp.Table{}
+	for k, v := range e.Headers() {
+		headers[k] = v
+	}
+	return amqp.Publishing{
+		ContentType:  ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    e.ID,
+		Type:         e.Type,
+		Timestamp:    e.Time,
+		Headers:      headers,
+		Body:         body,
+	}, nil
+}
+
+// Decode parses a message body into an envelope at the current schema
+// version. Bodies without an envelope predate it and are treated as version
+// 1 of fallbackType.
+func Decode(body []byte, fallbackType string) (Envelope, error) {
+	var probe struct {
+		SpecVersion *string `json:"specversion"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return Envelope{}, err
+	}
+
+	var env Envelope
+	if probe.SpecVersion == nil {
+		if fallbackType == "" {
+			return Envelope{}, fmt.Errorf("%w: message has no envelope", ErrUnknownType)
+		}
+		env = Envelope{
+			SpecVersion:     SpecVersion,
+			Type:            fallbackType,
+			DataContentType: "application/json",
+			SchemaVersion:   1,
+			Data:            json.RawMessage(body),
+		}
+	} else {
+		if err := json.Unmarshal(body, &env); err != nil {
+			return Envelope{}, err
+		}
+		if !strings.HasPrefix(env.SpecVersion, "1.") {
+			return Envelope{}, fmt.Errorf("%w: %s", ErrUnsupportedSpec, env.SpecVersion)
+		}
+		if env.SchemaVersion == 0 {
+			env.SchemaVersion = 1
+		}
+	}
+
+	if err := upcast(&env); err != nil {
+		return Envelope{}, err
+	}
+	return env, nil
+}
+
+// DecodeDelivery is Decode for an AMQP delivery, using the routing key to
+// type legacy messages.
+func DecodeDelivery(d amqp.Delivery) (Envelope, error) {
+	fallback := LegacyType(d.RoutingKey)
+	if fallback == "" {
+		fallback = LegacyType(d.Type)
+	}
+	return Decode(d.Body, fallback)
+}
+
+func upcast(env *Envelope) error {
+	current, ok := currentVersions[env.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, env.Type)
+	}
+	if env.SchemaVersion > current {
+		return fmt.Errorf("%w: %s v%d (supported up to v%d)", ErrIncompatibleVersion, env.Type, env.SchemaVersion, current)
+	}
+	for env.SchemaVersion < current {
+		up := upcasters[env.Type][env.SchemaVersion]
+		if up == nil {
+			return fmt.Errorf("no upcaster for %s v%d", env.Type, env.SchemaVersion)
+		}
+		data, err := up(env.Data)
+		if err != nil {
+			return fmt.Errorf("upcast %s v%d: %w", env.Type, env.SchemaVersion, err)
+		}
+		env.Data = data
+		env.SchemaVersion++
+		env.DataSchema = schemaURI(env.Type, env.SchemaVersion)
+	}
+	return nil
+}
+
+// DataAs decodes the envelope's data into out after checking the type.
+func (e Envelope) DataAs(eventType string, out interface{}) error {
+	if e.Type != eventType {
+		return fmt.Errorf("%w: expected %s, got %s", ErrUnknownType, eventType, e.Type)
+	}
+	return json.Unmarshal(e.Data, out)
+}
+
+// IsPermanent reports whether a decode error will never succeed on retry.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrUnknownType) || errors.Is(err, ErrUnsupportedSpec) || errors.Is(err, ErrIncompatibleVersion)
+}
@@ -0,0 +1,160 @@
+package events
+
+import (
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestDecode(t *testing.T) {
+	current, err := New("/report-service", TypeReportSubmitted, "r1", ReportSubmitted{ID: "r1", Title: "Sampah", Priority: "HIGH"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	currentBody, err := current.Publishing()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		body         string
+		fallbackType string
+		wantErr      error
+		wantPerm     bool
+		wantType     string
+		wantVersion  int
+		wantPriority string
+	}{
+		{
+			name:         "legacy v1 body without envelope",
+			body:         `{"id":"r1","title":"Sampah","category":"Kebersihan"}`,
+			fallbackType: TypeReportSubmitted,
+			wantType:     TypeReportSubmitted,
+			wantVersion:  2,
+			wantPriority: "normal",
+		},
+		{
+			name:         "v1 envelope is upcast",
+			body:         `{"specversion":"1.0","type":"citizen-reporting.report.submitted","schemaversion":1,"data":{"id":"r1"}}`,
+			wantType:     TypeReportSubmitted,
+			wantVersion:  2,
+			wantPriority: "normal",
+		},
+		{
+			name:         "envelope without schemaversion is v1",
+			body:         `{"specversion":"1.0","type":"citizen-reporting.report.submitted","data":{"id":"r1","priority":"LOW"}}`,
+			wantType:     TypeReportSubmitted,
+			wantVersion:  2,
+			wantPriority: "LOW",
+		},
+		{
+			name:         "current version passes through",
+			body:         string(currentBody.Body),
+			wantType:     TypeReportSubmitted,
+			wantVersion:  2,
+			wantPriority: "HIGH",
+		},
+		{
+			name:         "type without upcasters",
+			body:         `{"id":"n1","report_id":"r1"}`,
+			fallbackType: TypeReportUpdated,
+			wantType:     TypeReportUpdated,
+			wantVersion:  1,
+		},
+		{
+			name:     "legacy body with unknown routing key",
+			body:     `{"id":"r1"}`,
+			wantErr:  ErrUnknownType,
+			wantPerm: true,
+		},
+		{
+			name:     "unknown envelope type",
+			body:     `{"specversion":"1.0","type":"citizen-reporting.other","data":{}}`,
+			wantErr:  ErrUnknownType,
+			wantPerm: true,
+		},
+		{
+			name:     "newer schema version",
+			body:     `{"specversion":"1.0","type":"citizen-reporting.report.submitted","schemaversion":3,"data":{}}`,
+			wantErr:  ErrIncompatibleVersion,
+			wantPerm: true,
+		},
+		{
+			name:     "unsupported specversion",
+			body:     `{"specversion":"0.3","type":"citizen-reporting.report.submitted","data":{}}`,
+			wantErr:  ErrUnsupportedSpec,
+			wantPerm: true,
+		},
+		{
+			name:     "v1 data that cannot be upcast",
+			body:     `{"specversion":"1.0","type":"citizen-reporting.report.submitted","schemaversion":1,"data":{"title":42}}`,
+			wantPerm: false,
+			wantErr:  errAny,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := Decode([]byte(tt.body), tt.fallbackType)
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("Decode() = %+v, want error", env)
+				}
+				if tt.wantErr != errAny && !errors.Is(err, tt.wantErr) {
+					t.Errorf("Decode() error = %v, want %v", err, tt.wantErr)
+				}
+				if IsPermanent(err) != tt.wantPerm {
+					t.Errorf("IsPermanent(%v) = %v, want %v", err, !tt.wantPerm, tt.wantPerm)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if env.Type != tt.wantType || env.SchemaVersion != tt.wantVersion {
+				t.Errorf("Decode() = %s v%d, want %s v%d", env.Type, env.SchemaVersion, tt.wantType, tt.wantVersion)
+			}
+			if want := schemaURI(tt.wantType, tt.wantVersion); env.DataSchema != "" && env.DataSchema != want {
+				t.Errorf("DataSchema = %q, want %q", env.DataSchema, want)
+			}
+			if tt.wantType != TypeReportSubmitted {
+				return
+			}
+			var data ReportSubmitted
+			if err := env.DataAs(TypeReportSubmitted, &data); err != nil {
+				t.Fatalf("DataAs: %v", err)
+			}
+			if data.ID != "r1" || data.Priority != tt.wantPriority {
+				t.Errorf("data = {ID:%q Priority:%q}, want {ID:%q Priority:%q}", data.ID, data.Priority, "r1", tt.wantPriority)
+			}
+		})
+	}
+}
+
+// errAny marks a case that must fail without naming a sentinel.
+var errAny = errors.New("any error")
+
+func TestDecodeDeliveryLegacyRouting(t *testing.T) {
+	tests := []struct {
+		name       string
+		routingKey string
+		msgType    string
+		want       string
+	}{
+		{"routing key", "report.created", "", TypeReportCreated},
+		{"report queue", "report_queue", "", TypeReportSubmitted},
+		{"type after retry republish", "notifications.retry.2000ms", "report.updated", TypeReportUpdated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := DecodeDelivery(amqp.Delivery{RoutingKey: tt.routingKey, Type: tt.msgType, Body: []byte(`{"id":"x"}`)})
+			if err != nil {
+				t.Fatalf("DecodeDelivery: %v", err)
+			}
+			if env.Type != tt.want {
+				t.Errorf("Type = %q, want %q", env.Type, tt.want)
+			}
+		})
+	}
+}
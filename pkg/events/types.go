@@ -0,0 +1,97 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types. Routing keys on the "reports" exchange are unchanged; these
+// are the CloudEvents "type" attribute.
+const (
+	TypeReportSubmitted = "citizen-reporting.report.submitted"
+	TypeReportCreated   = "citizen-reporting.report.created"
+	TypeReportUpdated   = "citizen-reporting.report.updated"
+)
+
+// currentVersions is the schema version each type is published with.
+// Consumers upcast anything older and reject anything newer.
+var currentVersions = map[string]int{
+	TypeReportSubmitted: 2,
+	TypeReportCreated:   1,
+	TypeReportUpdated:   1,
+}
+
+// ReportSubmitted asks the dispatcher to route a new report.
+//
+// v1: id, title, description, category, is_anonymous, reporter_id,
+// reporter_name, created_at.
+// v2: adds subcategory, region and priority, and the optional location.
+//
+// Description and location are AES-GCM encrypted with APP_ENCRYPTION_KEY, as
+// stored on the report; the dispatcher decrypts them before delivery.
+type ReportSubmitted struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Location    string    `json:"location,omitempty"`
+	Category    string    `json:"category"`
+	Subcategory string    `json:"subcategory,omitempty"`
+	Region      string    `json:"region,omitempty"`
+	Priority    string    `json:"priority,omitempty"`
+	IsAnonymous bool      `json:"is_anonymous"`
+	ReporterID  string    `json:"reporter_id"`
+	Reporter    string    `json:"reporter_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Notification is the payload of report.created and report.updated, fanned
+// out to citizens and admins by notification-service.
+type Notification struct {
+	ID        string    `json:"id"`
+	ReportID  string    `json:"report_id"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Type      string    `json:"type"`
+	Status    string    `json:"status"`
+	Category  string    `json:"category,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Departments narrows an admin notification to these departments
+	// (canonical keys such as "kebersihan"); empty means by category.
+	Departments []string `json:"departments,omitempty"`
+	// UserIDs delivers a citizen notification to each of these users, such
+	// as the followers of a report. Publishers cap the list per event.
+	UserIDs []string `json:"user_ids,omitempty"`
+}
+
+type upcaster func(data json.RawMessage) (json.RawMessage, error)
+
+// upcasters[type][v] turns version v data into version v+1.
+var upcasters = map[string]map[int]upcaster{
+	TypeReportSubmitted: {
+		1: func(data json.RawMessage) (json.RawMessage, error) {
+			var v2 ReportSubmitted
+			if err := json.Unmarshal(data, &v2); err != nil {
+				return nil, err
+			}
+			if v2.Priority == "" {
+				v2.Priority = "normal"
+			}
+			return json.Marshal(v2)
+		},
+	},
+}
+
+// LegacyType maps the routing key of a pre-envelope message to its type.
+func LegacyType(routingKey string) string {
+	switch routingKey {
+	case "report.created":
+		return TypeReportCreated
+	case "report.updated":
+		return TypeReportUpdated
+	case "report_queue":
+		return TypeReportSubmitted
+	default:
+		return ""
+	}
+}
@@ -51,6 +51,7 @@ func RegisterMetrics() {
 		prometheus.MustRegister(httpRequestDuration)
 		prometheus.MustRegister(httpRequestsInProgress)
 		prometheus.MustRegister(serviceUptime)
+		prometheus.MustRegister(internalAuthRejected)
 		metricsRegistered = true
 
 		go func() {
@@ -1,30 +1,116 @@
 package middleware
 
 import (
+	"bufio"
 	"context"
+	"crypto/rand"
+	"errors"
+	"net"
 	"net/http"
+	"strings"
 
-	"github.com/google/uuid"
+	"citizen-reporting-system/pkg/telemetry"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const traceIDContextKey = "trace_id"
 
+// TraceMiddleware continues the caller's W3C trace (traceparent) or starts a
+// new one, wraps the request in a server span and exposes the trace ID as
+// X-Trace-Id so logs, audit entries and traces share one identifier. Without
+// a traceparent, a caller's X-Trace-Id in trace ID form (32 hex digits) is
+// kept as the trace ID, so clients and gateway logs that correlate on the ID
+// they sent still match; any other value is only recorded on the span.
 func TraceMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		traceID := r.Header.Get("X-Trace-Id")
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		legacy := r.Header.Get("X-Trace-Id")
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			ctx = adoptLegacyTraceID(ctx, legacy)
+		}
+		ctx, span := telemetry.Tracer().Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", r.RemoteAddr),
+			),
+		)
+		defer span.End()
 
-		if traceID == "" {
-			traceID = uuid.New().String()
+		if legacy != "" {
+			span.SetAttributes(attribute.String("x_trace_id", legacy))
 		}
 
+		traceID := telemetry.TraceID(ctx)
 		w.Header().Set("X-Trace-Id", traceID)
 
-		ctx := context.WithValue(r.Context(), traceIDContextKey, traceID)
+		ctx = context.WithValue(ctx, traceIDContextKey, traceID)
+
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ctx))
 
-		next.ServeHTTP(w, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.response.status_code", rw.status))
+		if rw.status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(rw.status))
+		}
 	})
 }
 
+// adoptLegacyTraceID makes id the trace of the request's server span. The
+// parent span ID is made up, as the caller never had a span.
+func adoptLegacyTraceID(ctx context.Context, id string) context.Context {
+	traceID, err := trace.TraceIDFromHex(strings.ToLower(strings.TrimSpace(id)))
+	if err != nil {
+		return ctx
+	}
+	var spanID trace.SpanID
+	if _, err := rand.Read(spanID[:]); err != nil {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+}
+
+// statusRecorder captures the status code but stays transparent to
+// streaming handlers by forwarding Flush and Hijack.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
 func GetTraceID(r *http.Request) string {
 	if traceID, ok := r.Context().Value(traceIDContextKey).(string); ok {
 		return traceID
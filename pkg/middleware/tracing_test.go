@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"citizen-reporting-system/pkg/telemetry"
+)
+
+func TestTraceMiddlewareTraceID(t *testing.T) {
+	shutdown := telemetry.Init("test")
+	defer shutdown(context.Background())
+
+	const (
+		sent   = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parent = "0af7651916cd43dd8448eb211c80319c"
+	)
+	tests := []struct {
+		name        string
+		traceparent string
+		xTraceID    string
+		want        string // empty means a fresh trace ID
+	}{
+		{"no headers", "", "", ""},
+		{"x-trace-id in trace ID form", "", sent, sent},
+		{"x-trace-id in upper case", "", "4BF92F3577B34DA6A3CE929D0E0E4736", sent},
+		{"legacy free-form x-trace-id", "", "web-1741600000000-abc123xyz", ""},
+		{"all-zero x-trace-id", "", "00000000000000000000000000000000", ""},
+		{"traceparent wins", "00-" + parent + "-b7ad6b7169203331-01", sent, parent},
+	}
+	hexID := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			h := TraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = GetTraceID(r)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/reports", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			if tt.xTraceID != "" {
+				req.Header.Set("X-Trace-Id", tt.xTraceID)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			got := rec.Header().Get("X-Trace-Id")
+			if got != seen {
+				t.Errorf("response X-Trace-Id %q differs from request trace ID %q", got, seen)
+			}
+			if !hexID.MatchString(got) || got == "00000000000000000000000000000000" {
+				t.Fatalf("X-Trace-Id = %q, want a valid trace ID", got)
+			}
+			if tt.want != "" && got != tt.want {
+				t.Errorf("X-Trace-Id = %q, want %q", got, tt.want)
+			}
+			if tt.want == "" && (got == sent || got == parent) {
+				t.Errorf("X-Trace-Id = %q, want a fresh trace ID", got)
+			}
+		})
+	}
+}
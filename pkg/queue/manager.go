@@ -0,0 +1,475 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"citizen-reporting-system/pkg/telemetry"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var ErrManagerClosed = errors.New("rabbitmq manager closed")
+
+type TopologyFunc func(ch *amqp.Channel) error
+
+type HealthState struct {
+	Connected      bool       `json:"connected"`
+	ConnectedSince *time.Time `json:"connected_since,omitempty"`
+	Reconnects     int        `json:"reconnects"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+	Consumers      int        `json:"consumers"`
+	IdleChannels   int        `json:"idle_publish_channels"`
+}
+
+type ManagerOptions struct {
+	PublishChannels int
+	MinBackoff      time.Duration
+	MaxBackoff      time.Duration
+	PublishTimeout  time.Duration
+}
+
+// Manager owns a single AMQP connection and keeps it alive. Topology
+// callbacks and consumers are replayed after every reconnect, and publishes
+// go through a pool of confirm-mode channels so concurrent request
+// goroutines never share a channel.
+type Manager struct {
+	uri  string
+	opts ManagerOptions
+
+	mu         sync.RWMutex
+	conn       *amqp.Connection
+	ready      chan struct{}
+	topology   []TopologyFunc
+	consumers  int
+	since      time.Time
+	reconnects int
+	lastErr    string
+	lastErrAt  time.Time
+
+	pool   chan *amqp.Channel
+	closed chan struct{}
+	once   sync.Once
+}
+
+func NewManager(uri string, opts ManagerOptions) *Manager {
+	if opts.PublishChannels <= 0 {
+		opts.PublishChannels = 8
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 500 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+	if opts.PublishTimeout <= 0 {
+		opts.PublishTimeout = 5 * time.Second
+	}
+
+	return &Manager{
+		uri:    uri,
+		opts:   opts,
+		ready:  make(chan struct{}),
+		pool:   make(chan *amqp.Channel, opts.PublishChannels),
+		closed: make(chan struct{}),
+	}
+}
+
+// OnConnect registers a topology declaration that runs on every connection
+// before consumers are (re)started. Register before Start.
+func (m *Manager) OnConnect(fn TopologyFunc) {
+	m.mu.Lock()
+	m.topology = append(m.topology, fn)
+	m.mu.Unlock()
+}
+
+func (m *Manager) Start() {
+	go m.run()
+}
+
+// WaitReady blocks until the first connection is established or ctx ends.
+func (m *Manager) WaitReady(ctx context.Context) error {
+	for {
+		m.mu.RLock()
+		ready := m.ready
+		m.mu.RUnlock()
+
+		select {
+		case <-ready:
+			return nil
+		case <-m.closed:
+			return ErrManagerClosed
+		case <-ctx.Done():
+			m.mu.RLock()
+			lastErr := m.lastErr
+			m.mu.RUnlock()
+			if lastErr != "" {
+				return fmt.Errorf("rabbitmq not ready: %s", lastErr)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+func (m *Manager) Close() error {
+	m.once.Do(func() { close(m.closed) })
+
+	m.mu.Lock()
+	conn := m.conn
+	m.conn = nil
+	m.mu.Unlock()
+
+	if conn != nil && !conn.IsClosed() {
+		return conn.Close()
+	}
+	return nil
+}
+
+func (m *Manager) Health() HealthState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state := HealthState{
+		Connected:    m.conn != nil && !m.conn.IsClosed(),
+		Reconnects:   m.reconnects,
+		LastError:    m.lastErr,
+		Consumers:    m.consumers,
+		IdleChannels: len(m.pool),
+	}
+	if state.Connected {
+		since := m.since
+		state.ConnectedSince = &since
+	}
+	if !m.lastErrAt.IsZero() {
+		at := m.lastErrAt
+		state.LastErrorAt = &at
+	}
+	return state
+}
+
+func (m *Manager) recordError(err error) {
+	m.mu.Lock()
+	m.lastErr = err.Error()
+	m.lastErrAt = time.Now()
+	m.mu.Unlock()
+}
+
+func (m *Manager) backoff(attempt int) time.Duration {
+	d := m.opts.MinBackoff << uint(attempt)
+	if d <= 0 || d > m.opts.MaxBackoff {
+		d = m.opts.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (m *Manager) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-m.closed:
+		return false
+	}
+}
+
+func (m *Manager) run() {
+	attempt := 0
+	for {
+		select {
+		case <-m.closed:
+			return
+		default:
+		}
+
+		conn, err := m.dial()
+		if err != nil {
+			m.recordError(err)
+			log.Printf("[WARN] RabbitMQ connect failed (attempt %d): %v", attempt+1, err)
+			if !m.sleep(m.backoff(attempt)) {
+				return
+			}
+			attempt++
+			continue
+		}
+		attempt = 0
+
+		notify := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+		m.mu.Lock()
+		if !m.since.IsZero() {
+			m.reconnects++
+		}
+		m.conn = conn
+		m.since = time.Now()
+		close(m.ready)
+		m.mu.Unlock()
+
+		log.Println("[OK] Connected to RabbitMQ")
+
+		select {
+		case <-m.closed:
+			return
+		case amqpErr := <-notify:
+			m.mu.Lock()
+			m.conn = nil
+			m.ready = make(chan struct{})
+			m.mu.Unlock()
+			m.drainPool()
+
+			if amqpErr != nil {
+				m.recordError(amqpErr)
+				log.Printf("[WARN] RabbitMQ connection lost: %v; reconnecting", amqpErr)
+			} else {
+				log.Println("[WARN] RabbitMQ connection closed; reconnecting")
+			}
+		}
+	}
+}
+
+func (m *Manager) dial() (*amqp.Connection, error) {
+	conn, err := amqp.Dial(m.uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to rabbitmq: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	m.mu.RLock()
+	topology := append([]TopologyFunc(nil), m.topology...)
+	m.mu.RUnlock()
+
+	for _, fn := range topology {
+		if err := fn(ch); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to declare topology: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (m *Manager) drainPool() {
+	for {
+		select {
+		case ch := <-m.pool:
+			if !ch.IsClosed() {
+				ch.Close()
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Channel opens a fresh channel on the current connection, waiting for a
+// reconnect if necessary. The caller owns and must close it.
+func (m *Manager) Channel(ctx context.Context) (*amqp.Channel, error) {
+	if err := m.WaitReady(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	conn := m.conn
+	m.mu.RUnlock()
+	if conn == nil {
+		return nil, errors.New("rabbitmq connection unavailable")
+	}
+	return conn.Channel()
+}
+
+func (m *Manager) acquirePublisher(ctx context.Context) (*amqp.Channel, error) {
+	for {
+		select {
+		case ch := <-m.pool:
+			if !ch.IsClosed() {
+				return ch, nil
+			}
+			continue
+		default:
+		}
+
+		ch, err := m.Channel(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := ch.Confirm(false); err != nil {
+			ch.Close()
+			return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+		}
+		return ch, nil
+	}
+}
+
+func (m *Manager) releasePublisher(ch *amqp.Channel) {
+	if ch.IsClosed() {
+		return
+	}
+	select {
+	case m.pool <- ch:
+	default:
+		ch.Close()
+	}
+}
+
+// Publish sends msg and waits for the broker's confirm. Mandatory publishes
+// that cannot be routed are reported as errors. The trace context in ctx is
+// added to the message headers.
+func (m *Manager) Publish(ctx context.Context, exchange, routingKey string, mandatory bool, msg amqp.Publishing) error {
+	ctx, span, headers := telemetry.StartPublishSpan(ctx, exchange, routingKey, msg.Headers)
+	defer span.End()
+	msg.Headers = headers
+
+	err := m.publish(ctx, exchange, routingKey, mandatory, msg)
+	telemetry.RecordError(span, err)
+	return err
+}
+
+func (m *Manager) publish(ctx context.Context, exchange, routingKey string, mandatory bool, msg amqp.Publishing) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.opts.PublishTimeout)
+		defer cancel()
+	}
+
+	ch, err := m.acquirePublisher(ctx)
+	if err != nil {
+		return err
+	}
+
+	var returned chan amqp.Return
+	if mandatory {
+		returned = ch.NotifyReturn(make(chan amqp.Return, 1))
+	}
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, mandatory, false, msg)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to confirm message: %w", err)
+	}
+
+	if mandatory {
+		select {
+		case ret := <-returned:
+			ch.Close()
+			return fmt.Errorf("message returned by broker: %s", ret.ReplyText)
+		default:
+		}
+		// NotifyReturn listeners cannot be removed, so mandatory channels are not pooled.
+		ch.Close()
+	} else {
+		m.releasePublisher(ch)
+	}
+
+	if !acked {
+		return errors.New("broker nacked message")
+	}
+	return nil
+}
+
+type ConsumerSpec struct {
+	// Queue is consumed directly unless Setup returns another name, e.g. for
+	// a server-named exclusive queue.
+	Queue    string
+	Setup    func(ch *amqp.Channel) (string, error)
+	Prefetch int
+	AutoAck  bool
+	Tag      string
+}
+
+// Consume runs handler for every delivery on spec's queue and transparently
+// re-subscribes after the connection or channel is lost. It returns when
+// ctx is cancelled or the manager is closed.
+func (m *Manager) Consume(ctx context.Context, spec ConsumerSpec, handler func(ch *amqp.Channel, d amqp.Delivery)) {
+	m.mu.Lock()
+	m.consumers++
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		m.consumers--
+		m.mu.Unlock()
+	}()
+
+	attempt := 0
+	for {
+		err := m.consumeOnce(ctx, spec, handler)
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.closed:
+			return
+		default:
+		}
+
+		if err != nil {
+			m.recordError(err)
+			log.Printf("[WARN] Consumer on '%s' stopped: %v; re-subscribing", spec.Queue, err)
+			if !m.sleep(m.backoff(attempt)) {
+				return
+			}
+			attempt++
+			continue
+		}
+		attempt = 0
+	}
+}
+
+func (m *Manager) consumeOnce(ctx context.Context, spec ConsumerSpec, handler func(ch *amqp.Channel, d amqp.Delivery)) error {
+	ch, err := m.Channel(ctx)
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	queueName := spec.Queue
+	if spec.Setup != nil {
+		name, err := spec.Setup(ch)
+		if err != nil {
+			return fmt.Errorf("failed to set up consumer: %w", err)
+		}
+		if name != "" {
+			queueName = name
+		}
+	}
+
+	if spec.Prefetch > 0 {
+		if err := ch.Qos(spec.Prefetch, 0, false); err != nil {
+			return fmt.Errorf("failed to set prefetch: %w", err)
+		}
+	}
+
+	msgs, err := ch.Consume(queueName, spec.Tag, spec.AutoAck, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to register consumer: %w", err)
+	}
+
+	log.Printf("[INFO] Consuming from '%s'", queueName)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, ok := <-msgs:
+			if !ok {
+				return errors.New("delivery channel closed")
+			}
+			handler(ch, d)
+		}
+	}
+}
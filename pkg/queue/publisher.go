@@ -2,49 +2,15 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"time"
 
-	amqp "github.com/rabbitmq/amqp091-go"
+	"citizen-reporting-system/pkg/events"
 )
 
-func PublishMessage(ch *amqp.Channel, queueName string, payload interface{}) error {
-	_, err := ch.QueueDeclare(
-		queueName,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+// PublishEvent publishes a CloudEvents envelope in structured mode.
+func (m *Manager) PublishEvent(ctx context.Context, exchange, routingKey string, env events.Envelope) error {
+	msg, err := env.Publishing()
 	if err != nil {
-		return fmt.Errorf("failed to declare queue: %w", err)
+		return err
 	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	err = ch.PublishWithContext(ctx,
-		"",
-		queueName,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType:  "application/json",
-			DeliveryMode: amqp.Persistent,
-			Body:         body,
-			Timestamp:    time.Now(),
-		})
-
-	if err != nil {
-		return fmt.Errorf("failed to publish message: %w", err)
-	}
-
-	return nil
+	return m.Publish(ctx, exchange, routingKey, false, msg)
 }
@@ -2,48 +2,28 @@ package queue
 
 import (
 	"fmt"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-func ConnectRabbitMQ(uri string) (*amqp.Connection, *amqp.Channel, error) {
-	conn, err := amqp.Dial(uri)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to connect to rabbitmq: %w", err)
-	}
-
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to open channel: %w", err)
-	}
-
-	return conn, ch, nil
-}
-
-func ConsumeMessages(ch *amqp.Channel, queueName string) (<-chan amqp.Delivery, error) {
+func DeclareQueue(ch *amqp.Channel, queueName string, args amqp.Table) error {
 	_, err := ch.QueueDeclare(
 		queueName,
 		true,
 		false,
 		false,
 		false,
-		nil,
+		args,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to declare queue: %w", err)
+		return fmt.Errorf("failed to declare queue %s: %w", queueName, err)
 	}
+	return nil
+}
 
-	msgs, err := ch.Consume(
-		queueName,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to register consumer: %w", err)
+func DeclareExchange(ch *amqp.Channel, exchange, kind string) error {
+	if err := ch.ExchangeDeclare(exchange, kind, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare exchange %s: %w", exchange, err)
 	}
-
-	return msgs, nil
-}
\ No newline at end of file
+	return nil
+}
@@ -0,0 +1,64 @@
+package security
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// internalHostSuffixes are names that only resolve inside a network.
+var internalHostSuffixes = []string{".localhost", ".local", ".internal", ".lan", ".home.arpa", ".svc", ".cluster.local"}
+
+// cgnatPrefix is the carrier-grade NAT range, private in practice.
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// PublicAddr reports whether ip is a routable public unicast address.
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !cgnatPrefix.Contains(ip)
+}
+
+// ValidatePublicURL accepts only https URLs whose host is a public IP or a
+// dotted name outside internal domains, for requests the services make to
+// addresses supplied by users or partners. Single-label names such as
+// "mongo" are container hostnames.
+func ValidatePublicURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return nil, fmt.Errorf("url must be an absolute https URL")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !PublicAddr(ip) {
+			return nil, fmt.Errorf("url must not point to a loopback, private or link-local address")
+		}
+		return u, nil
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return nil, fmt.Errorf("url host must be a public domain name")
+	}
+	for _, suffix := range internalHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return nil, fmt.Errorf("url host must be a public domain name")
+		}
+	}
+	return u, nil
+}
+
+// PublicDialControl is a net.Dialer Control that refuses connections to
+// non-public addresses, whatever DNS returned for a name that passed
+// ValidatePublicURL.
+func PublicDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !PublicAddr(ip) {
+		return fmt.Errorf("address %s is not public", host)
+	}
+	return nil
+}
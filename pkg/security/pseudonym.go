@@ -0,0 +1,77 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	PseudonymPrefix        = "ANON2_"
+	PseudonymPeriodNone    = ""
+	PseudonymPeriodMonth   = "month"
+	PseudonymPeriodQuarter = "quarter"
+	PseudonymPeriodYear    = "year"
+)
+
+// pseudonymKey reads ANON_ID_SECRET, hex or raw. There is deliberately no
+// fallback: anyone holding the key can recompute pseudonyms from a user list.
+func pseudonymKey() []byte {
+	v := strings.TrimSpace(os.Getenv("ANON_ID_SECRET"))
+	if key, err := hex.DecodeString(v); err == nil && len(key) >= 16 {
+		return key
+	}
+	return []byte(v)
+}
+
+// CheckPseudonymKey reports whether ANON_ID_SECRET is usable; services that
+// derive pseudonyms refuse to start without it.
+func CheckPseudonymKey() error {
+	if len(pseudonymKey()) < 16 {
+		return errors.New("ANON_ID_SECRET must be set to at least 16 bytes (32 hex characters)")
+	}
+	return nil
+}
+
+// PseudonymPeriod returns the configured rotation period (ANON_ID_PERIOD).
+// An empty value keeps one stable pseudonym per user.
+func PseudonymPeriod() string {
+	switch p := strings.ToLower(strings.TrimSpace(os.Getenv("ANON_ID_PERIOD"))); p {
+	case PseudonymPeriodMonth, PseudonymPeriodQuarter, PseudonymPeriodYear:
+		return p
+	default:
+		return PseudonymPeriodNone
+	}
+}
+
+// PeriodLabel maps a timestamp to the label of the reporting period it falls in.
+func PeriodLabel(period string, t time.Time) string {
+	t = t.UTC()
+	switch period {
+	case PseudonymPeriodMonth:
+		return t.Format("2006-01")
+	case PseudonymPeriodQuarter:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	case PseudonymPeriodYear:
+		return t.Format("2006")
+	default:
+		return ""
+	}
+}
+
+// PseudonymousID derives the anonymous reporter ID for a user within a
+// reporting period label. Without the secret the mapping cannot be reversed
+// or recomputed from a user list.
+func PseudonymousID(userID, periodLabel string) string {
+	mac := hmac.New(sha256.New, pseudonymKey())
+	mac.Write([]byte("reporter-id:v2\x00"))
+	mac.Write([]byte(periodLabel))
+	mac.Write([]byte{0})
+	mac.Write([]byte(userID))
+	return PseudonymPrefix + hex.EncodeToString(mac.Sum(nil))[:32]
+}
@@ -0,0 +1,71 @@
+package telemetry
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type amqpCarrier amqp.Table
+
+func (c amqpCarrier) Get(key string) string {
+	if v, ok := c[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func (c amqpCarrier) Set(key, value string) { c[key] = value }
+
+func (c amqpCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// StartPublishSpan starts a producer span and returns a copy of headers with
+// its traceparent, leaving the caller's table untouched.
+func StartPublishSpan(ctx context.Context, exchange, routingKey string, headers amqp.Table) (context.Context, trace.Span, amqp.Table) {
+	dest := exchange
+	if dest == "" {
+		dest = routingKey
+	}
+	ctx, span := Tracer().Start(ctx, "publish "+dest,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", exchange),
+			attribute.String("messaging.rabbitmq.destination.routing_key", routingKey),
+		),
+	)
+
+	out := amqp.Table{}
+	for k, v := range headers {
+		out[k] = v
+	}
+	otel.GetTextMapPropagator().Inject(ctx, amqpCarrier(out))
+	return ctx, span, out
+}
+
+// StartConsumeSpan continues the trace carried in the delivery's headers.
+// The caller ends the span once the message is handled.
+func StartConsumeSpan(d amqp.Delivery, queue string) (context.Context, trace.Span) {
+	ctx := context.Background()
+	if d.Headers != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, amqpCarrier(d.Headers))
+	}
+	return Tracer().Start(ctx, "process "+queue,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", queue),
+			attribute.String("messaging.message.id", d.MessageId),
+			attribute.String("messaging.rabbitmq.destination.routing_key", d.RoutingKey),
+		),
+	)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/services/auth-service/models"
+)
+
+const (
+	residentsPageSize    = 500
+	residentsMaxPageSize = 2000
+)
+
+// Region codes are hierarchical administrative codes such as "31.71.01".
+var regionCodeRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
+// homeAreaHandler sets (PUT) or clears (DELETE) the caller's home area,
+// which decides the area announcements they receive:
+//
+//	{"region": "31.71.01", "lat": -6.2, "lng": 106.8}
+//
+// Either part is optional, but lat and lng go together.
+func homeAreaHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims)
+	if !ok {
+		response.Error(w, http.StatusInternalServerError, "Failed to retrieve user context", "")
+		return
+	}
+
+	updates := map[string]interface{}{}
+	switch r.Method {
+	case http.MethodPut:
+		var input struct {
+			Region string   `json:"region"`
+			Lat    *float64 `json:"lat"`
+			Lng    *float64 `json:"lng"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid request payload", "")
+			return
+		}
+		input.Region = strings.TrimSpace(input.Region)
+		if input.Region != "" && !regionCodeRegex.MatchString(input.Region) {
+			response.Error(w, http.StatusBadRequest, "Invalid region code", "")
+			return
+		}
+		if (input.Lat == nil) != (input.Lng == nil) {
+			response.Error(w, http.StatusBadRequest, "lat and lng must be set together", "")
+			return
+		}
+		if input.Lat != nil && (*input.Lat < -90 || *input.Lat > 90 || *input.Lng < -180 || *input.Lng > 180) {
+			response.Error(w, http.StatusBadRequest, "Coordinates out of range", "")
+			return
+		}
+		if input.Region == "" && input.Lat == nil {
+			response.Error(w, http.StatusBadRequest, "Region or coordinates are required", "")
+			return
+		}
+		updates["home_region"] = input.Region
+		updates["home_lat"] = input.Lat
+		updates["home_lng"] = input.Lng
+	case http.MethodDelete:
+		updates["home_region"] = ""
+		updates["home_lat"] = nil
+		updates["home_lng"] = nil
+	default:
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	var user models.User
+	if err := db.WithContext(r.Context()).First(&user, "id = ?", claims.UserID).Error; err != nil {
+		response.Error(w, http.StatusNotFound, "User not found", "")
+		return
+	}
+	if err := db.WithContext(r.Context()).Model(&user).Updates(updates).Error; err != nil {
+		log.Printf("[ERROR] Failed to update home area for user %s: %v", claims.UserID, err)
+		response.Error(w, http.StatusInternalServerError, "Failed to update home area", "")
+		return
+	}
+	if err := db.WithContext(r.Context()).First(&user, "id = ?", claims.UserID).Error; err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to reload user", "")
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Home area updated", map[string]interface{}{
+		"home_region": user.HomeRegion,
+		"home_lat":    user.HomeLat,
+		"home_lng":    user.HomeLng,
+	})
+}
+
+// internalResidentsHandler serves GET /internal/residents, the users whose
+// home area lies in an announcement's area. The area is either a region
+// code, matched with its sub-regions, or a bounding box (min_lat, max_lat,
+// min_lng, max_lng) that notification-service narrows to its polygon.
+// Results are ordered by ID; pass the last ID as after for the next page.
+func internalResidentsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+	q := r.URL.Query()
+
+	query := db.WithContext(r.Context()).Model(&models.User{})
+	if region := strings.TrimSpace(q.Get("region")); region != "" {
+		if !regionCodeRegex.MatchString(region) {
+			response.Error(w, http.StatusBadRequest, "Invalid region code", "")
+			return
+		}
+		query = query.Where("home_region = ? OR home_region LIKE ?", region, region+".%")
+	} else {
+		var box [4]float64
+		for i, key := range []string{"min_lat", "max_lat", "min_lng", "max_lng"} {
+			v, err := strconv.ParseFloat(q.Get(key), 64)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "region or a bounding box is required", "")
+				return
+			}
+			box[i] = v
+		}
+		query = query.Where("home_lat BETWEEN ? AND ? AND home_lng BETWEEN ? AND ?", box[0], box[1], box[2], box[3])
+	}
+	if after := q.Get("after"); after != "" {
+		query = query.Where("id > ?", after)
+	}
+
+	limit := residentsPageSize
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 {
+		limit = v
+	}
+	if limit > residentsMaxPageSize {
+		limit = residentsMaxPageSize
+	}
+
+	var users []models.User
+	if err := query.Order("id").Limit(limit).Find(&users).Error; err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch users", err.Error())
+		return
+	}
+
+	residents := make([]map[string]interface{}, 0, len(users))
+	for _, user := range users {
+		residents = append(residents, map[string]interface{}{
+			"id":          user.ID,
+			"home_region": user.HomeRegion,
+			"home_lat":    user.HomeLat,
+			"home_lng":    user.HomeLng,
+		})
+	}
+	response.Success(w, http.StatusOK, "Residents fetched", residents)
+}
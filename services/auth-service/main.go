@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -12,6 +13,7 @@ import (
 	"citizen-reporting-system/pkg/database"
 	"citizen-reporting-system/pkg/middleware"
 	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/pkg/telemetry"
 	"citizen-reporting-system/services/auth-service/models"
 	"citizen-reporting-system/services/auth-service/utils"
 
@@ -48,7 +50,18 @@ func isValidNIK(nik string) bool {
 	return true
 }
 
+// normalizeLanguage keeps the notification languages the templates support.
+func normalizeLanguage(lang string) string {
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(lang)), "en") {
+		return "en"
+	}
+	return "id"
+}
+
 func main() {
+	shutdownTracing := telemetry.Init("auth-service")
+	defer shutdownTracing(context.Background())
+
 	dsn := fmt.Sprintf(
 		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
 		os.Getenv("POSTGRES_HOST"),
@@ -82,6 +95,10 @@ func main() {
 	mux.HandleFunc("/api/auth/register", registerHandler)
 	mux.HandleFunc("/api/auth/login", loginHandler)
 	mux.HandleFunc("/api/auth/me", middleware.AuthMiddleware(http.HandlerFunc(meHandler)).ServeHTTP)
+	mux.HandleFunc("/api/auth/me/home-area", middleware.AuthMiddleware(http.HandlerFunc(homeAreaHandler)).ServeHTTP)
+	mux.Handle("/internal/users", middleware.InternalAuthMiddleware(http.HandlerFunc(internalAdminsHandler), "notification-service"))
+	mux.Handle("/internal/users/", middleware.InternalAuthMiddleware(http.HandlerFunc(internalUserContactHandler), "notification-service"))
+	mux.Handle("/internal/residents", middleware.InternalAuthMiddleware(http.HandlerFunc(internalResidentsHandler), "notification-service"))
 	mux.HandleFunc("/health", healthCheckHandler)
 	mux.Handle("/metrics", middleware.GetMetricsHandler())
 	handler := middleware.TraceMiddleware(
@@ -92,7 +109,7 @@ func main() {
 
 	port := ":8081"
 	log.Printf("🚀 Auth Service running on port %s", port)
-	log.Println("🔍 Distributed tracing enabled (X-Trace-Id)")
+	log.Println("🔍 Distributed tracing enabled (W3C traceparent, X-Trace-Id)")
 	if err := http.ListenAndServe(port, handler); err != nil {
 		log.Fatalf("❌ Server failed: %v", err)
 	}
@@ -110,6 +127,7 @@ func registerHandler(w http.ResponseWriter, r *http.Request) {
 		Name     string `json:"name"`
 		NIK      string `json:"nik"`
 		Phone    string `json:"phone"`
+		Language string `json:"language"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -144,7 +162,7 @@ func registerHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var existingUser models.User
-	if result := db.Where("email = ?", input.Email).First(&existingUser); result.Error == nil {
+	if result := db.WithContext(r.Context()).Where("email = ?", input.Email).First(&existingUser); result.Error == nil {
 		log.Printf("[WARN] Registration attempt with existing email")
 		response.Error(w, http.StatusConflict, "Email already registered", "")
 		return
@@ -168,12 +186,13 @@ func registerHandler(w http.ResponseWriter, r *http.Request) {
 		Name:       strings.TrimSpace(input.Name),
 		NIK:        nikPtr,
 		Phone:      input.Phone,
+		Language:   normalizeLanguage(input.Language),
 		Role:       "citizen",
 		AccessRole: "operational",
 		Department: "general",
 	}
 
-	if err := db.Create(&newUser).Error; err != nil {
+	if err := db.WithContext(r.Context()).Create(&newUser).Error; err != nil {
 		log.Printf("[ERROR] Failed to save user to database: %v", err)
 		response.Error(w, http.StatusInternalServerError, "Failed to save user", "")
 		return
@@ -220,7 +239,7 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var user models.User
-	if err := db.Where("email = ?", input.Email).First(&user).Error; err != nil {
+	if err := db.WithContext(r.Context()).Where("email = ?", input.Email).First(&user).Error; err != nil {
 		log.Printf("[WARN] Failed login attempt")
 		response.Error(w, http.StatusUnauthorized, "Invalid email or password", "")
 		return
@@ -259,7 +278,7 @@ func meHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var user models.User
-	if err := db.First(&user, "id = ?", claims.UserID).Error; err != nil {
+	if err := db.WithContext(r.Context()).First(&user, "id = ?", claims.UserID).Error; err != nil {
 		response.Error(w, http.StatusNotFound, "User not found", "")
 		return
 	}
@@ -267,6 +286,71 @@ func meHandler(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, http.StatusOK, "User profile fetched", user)
 }
 
+// internalUserContactHandler serves GET /internal/users/{id}/contact so
+// notification-service can address email and SMS without a copy of the
+// user table.
+func internalUserContactHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+	rest := strings.TrimPrefix(r.URL.Path, "/internal/users/")
+	id, ok := strings.CutSuffix(rest, "/contact")
+	if !ok || id == "" || strings.Contains(id, "/") {
+		response.Error(w, http.StatusNotFound, "Not found", "")
+		return
+	}
+
+	var user models.User
+	if err := db.WithContext(r.Context()).First(&user, "id = ?", id).Error; err != nil {
+		response.Error(w, http.StatusNotFound, "User not found", "")
+		return
+	}
+
+	response.Success(w, http.StatusOK, "User contact fetched", map[string]interface{}{
+		"id":         user.ID,
+		"name":       user.Name,
+		"email":      user.Email,
+		"phone":      user.Phone,
+		"language":   normalizeLanguage(user.Language),
+		"role":       user.Role,
+		"department": user.Department,
+	})
+}
+
+// internalAdminsHandler serves GET /internal/users?role=admin, the admin
+// accounts notification-service fans department notifications out to.
+func internalAdminsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+	if r.URL.Query().Get("role") != "admin" {
+		response.Error(w, http.StatusBadRequest, "Only role=admin is supported", "")
+		return
+	}
+
+	query := db.WithContext(r.Context()).Where("role IN ?", []string{"admin", "super-admin"})
+	if department := r.URL.Query().Get("department"); department != "" {
+		query = query.Where("department = ?", department)
+	}
+	var users []models.User
+	if err := query.Order("created_at").Find(&users).Error; err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch users", err.Error())
+		return
+	}
+
+	admins := make([]map[string]interface{}, 0, len(users))
+	for _, user := range users {
+		admins = append(admins, map[string]interface{}{
+			"id":         user.ID,
+			"role":       user.Role,
+			"department": user.Department,
+		})
+	}
+	response.Success(w, http.StatusOK, "Admins fetched", admins)
+}
+
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	health := map[string]interface{}{
 		"status":  "UP",
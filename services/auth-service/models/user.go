@@ -11,11 +11,15 @@ type User struct {
 	Email      string         `gorm:"uniqueIndex;not null" json:"email"`
 	Password   string         `gorm:"not null" json:"-"`
 	Name       string         `gorm:"not null" json:"name"`
-	Role       string         `gorm:\"default:'citizen'\" json:\"role\"`
-	AccessRole string         `gorm:\"default:'operational'\" json:\"access_role\"`
+	Role       string         `gorm:"default:'citizen'" json:"role"`
+	AccessRole string         `gorm:"default:'operational'" json:"access_role"`
 	Department string         `gorm:"default:'general'" json:"department"`
 	NIK        *string        `gorm:"uniqueIndex" json:"nik,omitempty"`
 	Phone      string         `json:"phone,omitempty"`
+	Language   string         `gorm:"default:'id'" json:"language"`
+	HomeRegion string         `gorm:"index" json:"home_region,omitempty"`
+	HomeLat    *float64       `json:"home_lat,omitempty"`
+	HomeLng    *float64       `json:"home_lng,omitempty"`
 	CreatedAt  time.Time      `json:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at"`
 	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
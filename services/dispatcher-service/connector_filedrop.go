@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// FileDropConfig writes one CSV file per report, either into a local (or
+// mounted) directory or to a remote directory over SFTP.
+type FileDropConfig struct {
+	Mode           string `json:"mode"`
+	Dir            string `json:"dir"`
+	Host           string `json:"host,omitempty"`
+	Port           string `json:"port,omitempty"`
+	Username       string `json:"username,omitempty"`
+	Password       string `json:"password,omitempty"`
+	PrivateKeyFile string `json:"private_key_file,omitempty"`
+	HostKey        string `json:"host_key,omitempty"`
+}
+
+type fileDropConnector struct{}
+
+func (c *fileDropConnector) Name() string { return "file_drop" }
+
+func reportCSV(report ReportEvent, dept DepartmentConfig) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"report_id", "department", "title", "description", "location", "category", "reporter", "created_at", "dispatched_at"})
+	_ = w.Write([]string{
+		report.ID,
+		dept.Name,
+		report.Title,
+		report.Description,
+		report.Location,
+		report.Category,
+		report.Reporter,
+		report.CreatedAt.UTC().Format(time.RFC3339),
+		time.Now().UTC().Format(time.RFC3339),
+	})
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// The file name is derived from the report ID so a redelivery overwrites the
+// earlier drop instead of creating a duplicate.
+func fileDropName(report ReportEvent) string {
+	return fmt.Sprintf("report-%s.csv", report.ID)
+}
+
+func (c *fileDropConnector) Deliver(ctx context.Context, report ReportEvent, dept DepartmentConfig) (DeliveryReceipt, error) {
+	cfg := dept.FileDrop
+	if cfg == nil || cfg.Dir == "" {
+		return DeliveryReceipt{}, permanent(fmt.Errorf("file drop not configured for %s", dept.Name))
+	}
+
+	data, err := reportCSV(report, dept)
+	if err != nil {
+		return DeliveryReceipt{}, permanent(err)
+	}
+
+	switch strings.ToLower(cfg.Mode) {
+	case "", "local":
+		return c.deliverLocal(cfg, report, data)
+	case "sftp":
+		return c.deliverSFTP(ctx, cfg, report, data, dept)
+	default:
+		return DeliveryReceipt{}, permanent(fmt.Errorf("unknown file drop mode %q", cfg.Mode))
+	}
+}
+
+func (c *fileDropConnector) deliverLocal(cfg *FileDropConfig, report ReportEvent, data []byte) (DeliveryReceipt, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return DeliveryReceipt{}, err
+	}
+
+	final := filepath.Join(cfg.Dir, fileDropName(report))
+	tmp := final + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return DeliveryReceipt{}, err
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		os.Remove(tmp)
+		return DeliveryReceipt{}, err
+	}
+
+	return DeliveryReceipt{Status: ReceiptDelivered, Reference: final}, nil
+}
+
+func sftpClientConfig(cfg *FileDropConfig) (*ssh.ClientConfig, error) {
+	var auths []ssh.AuthMethod
+	if cfg.PrivateKeyFile != "" {
+		key, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		auths = append(auths, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		auths = append(auths, ssh.Password(cfg.Password))
+	}
+
+	if cfg.HostKey == "" {
+		return nil, fmt.Errorf("host_key is required for sftp")
+	}
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cfg.HostKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid host_key: %w", err)
+	}
+
+	return &ssh.ClientConfig{
+		User:            cfg.Username,
+		Auth:            auths,
+		HostKeyCallback: ssh.FixedHostKey(hostKey),
+	}, nil
+}
+
+func (c *fileDropConnector) deliverSFTP(ctx context.Context, cfg *FileDropConfig, report ReportEvent, data []byte, dept DepartmentConfig) (DeliveryReceipt, error) {
+	sshCfg, err := sftpClientConfig(cfg)
+	if err != nil {
+		return DeliveryReceipt{}, permanent(err)
+	}
+	port := cfg.Port
+	if port == "" {
+		port = "22"
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(cfg.Host, port))
+	if err != nil {
+		return DeliveryReceipt{}, fmt.Errorf("sftp %s: %w", dept.Name, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, conn.RemoteAddr().String(), sshCfg)
+	if err != nil {
+		conn.Close()
+		return DeliveryReceipt{}, fmt.Errorf("sftp %s: %w", dept.Name, err)
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer client.Close()
+
+	sc, err := sftp.NewClient(client)
+	if err != nil {
+		return DeliveryReceipt{}, fmt.Errorf("sftp %s: %w", dept.Name, err)
+	}
+	defer sc.Close()
+
+	final := path.Join(cfg.Dir, fileDropName(report))
+	tmp := final + ".tmp"
+
+	f, err := sc.Create(tmp)
+	if err != nil {
+		return DeliveryReceipt{}, fmt.Errorf("sftp %s: %w", dept.Name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return DeliveryReceipt{}, fmt.Errorf("sftp %s: %w", dept.Name, err)
+	}
+	if err := f.Close(); err != nil {
+		return DeliveryReceipt{}, fmt.Errorf("sftp %s: %w", dept.Name, err)
+	}
+	if err := sc.PosixRename(tmp, final); err != nil {
+		_ = sc.Remove(final)
+		if err := sc.Rename(tmp, final); err != nil {
+			return DeliveryReceipt{}, fmt.Errorf("sftp %s: %w", dept.Name, err)
+		}
+	}
+
+	return DeliveryReceipt{
+		Status:    ReceiptDelivered,
+		Reference: fmt.Sprintf("sftp://%s%s", cfg.Host, final),
+	}, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+)
+
+// manualConnector hands the report to department staff through the admin
+// dashboard. The QUEUED receipt is what puts it on the manual dispatch list
+// in report-service.
+type manualConnector struct{}
+
+func (c *manualConnector) Name() string { return "manual" }
+
+func (c *manualConnector) Deliver(ctx context.Context, report ReportEvent, dept DepartmentConfig) (DeliveryReceipt, error) {
+	return DeliveryReceipt{
+		Status:    ReceiptQueued,
+		Reference: idempotencyKey(report, dept),
+		Detail:    "awaiting manual handling in dashboard",
+	}, nil
+}
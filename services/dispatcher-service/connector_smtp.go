@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string   `json:"host"`
+	Port     string   `json:"port"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+type smtpConnector struct{}
+
+func (c *smtpConnector) Name() string { return "smtp" }
+
+func formatReportEmail(report ReportEvent, dept DepartmentConfig) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Laporan warga baru untuk %s\n", dept.Name)
+	b.WriteString(strings.Repeat("=", 48) + "\n\n")
+	fmt.Fprintf(&b, "ID Laporan : %s\n", report.ID)
+	fmt.Fprintf(&b, "Judul      : %s\n", report.Title)
+	fmt.Fprintf(&b, "Kategori   : %s\n", report.Category)
+	if report.Location != "" {
+		fmt.Fprintf(&b, "Lokasi     : %s\n", report.Location)
+	}
+	fmt.Fprintf(&b, "Pelapor    : %s\n", report.Reporter)
+	fmt.Fprintf(&b, "Dibuat     : %s\n\n", report.CreatedAt.Format("02 Jan 2006 15:04 MST"))
+	b.WriteString("Deskripsi:\n")
+	b.WriteString(report.Description)
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (c *smtpConnector) Deliver(ctx context.Context, report ReportEvent, dept DepartmentConfig) (DeliveryReceipt, error) {
+	cfg := dept.SMTP
+	if cfg == nil || cfg.Host == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return DeliveryReceipt{}, permanent(fmt.Errorf("smtp not configured for %s", dept.Name))
+	}
+	port := cfg.Port
+	if port == "" {
+		port = "587"
+	}
+
+	// A stable Message-ID lets the department's mail system drop a resend.
+	messageID := fmt.Sprintf("<%s@%s>", idempotencyKey(report, dept), cfg.Host)
+	subject := mime.QEncoding.Encode("utf-8", fmt.Sprintf("[Laporan %s] %s", report.Category, report.Title))
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "X-Report-ID: %s\r\n", report.ID)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(formatReportEmail(report, dept), "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	// net/smtp has no context support, so run it aside and honour the timeout.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(cfg.Host, port), auth, cfg.From, cfg.To, msg.Bytes())
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return DeliveryReceipt{}, fmt.Errorf("smtp %s: %w", dept.Name, err)
+		}
+	case <-ctx.Done():
+		return DeliveryReceipt{}, fmt.Errorf("smtp %s: %w", dept.Name, ctx.Err())
+	}
+
+	return DeliveryReceipt{
+		Status:    ReceiptDelivered,
+		Reference: messageID,
+		Detail:    "sent to " + strings.Join(cfg.To, ", "),
+	}, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Secret  string            `json:"secret"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type webhookConnector struct {
+	client *http.Client
+}
+
+func (c *webhookConnector) Name() string { return "webhook" }
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>", so the
+// receiver can reject replays with stale timestamps.
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *webhookConnector) Deliver(ctx context.Context, report ReportEvent, dept DepartmentConfig) (DeliveryReceipt, error) {
+	cfg := dept.Webhook
+	if cfg == nil || cfg.URL == "" {
+		return DeliveryReceipt{}, permanent(fmt.Errorf("webhook url not configured for %s", dept.Name))
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"event":      "report.dispatched",
+		"department": dept.Name,
+		"report":     report,
+		"sent_at":    time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return DeliveryReceipt{}, permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return DeliveryReceipt{}, permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", idempotencyKey(report, dept))
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if cfg.Secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set("X-Signature-Timestamp", strconv.FormatInt(ts, 10))
+		req.Header.Set("X-Signature", "sha256="+signWebhook(cfg.Secret, ts, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return DeliveryReceipt{}, fmt.Errorf("webhook %s: %w", dept.Name, err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("webhook %s returned %d", dept.Name, resp.StatusCode)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return DeliveryReceipt{}, permanent(err)
+		}
+		return DeliveryReceipt{}, err
+	}
+
+	var ack struct {
+		TicketID  string `json:"ticket_id"`
+		ID        string `json:"id"`
+		Reference string `json:"reference"`
+	}
+	_ = json.Unmarshal(respBody, &ack)
+
+	reference := ack.TicketID
+	if reference == "" {
+		reference = ack.Reference
+	}
+	if reference == "" {
+		reference = ack.ID
+	}
+
+	return DeliveryReceipt{
+		Status:    ReceiptDelivered,
+		Reference: reference,
+		Detail:    fmt.Sprintf("HTTP %d", resp.StatusCode),
+	}, nil
+}
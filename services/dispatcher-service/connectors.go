@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/security"
+	"citizen-reporting-system/pkg/telemetry"
+)
+
+const (
+	ReceiptDelivered = "DELIVERED"
+	ReceiptQueued    = "QUEUED"
+	ReceiptFailed    = "FAILED"
+)
+
+// DepartmentConnector delivers a routed report to a department's own system.
+// Deliver must be safe to call again for the same report: the webhook sends
+// idempotencyKey as a header, SMTP uses it as the Message-ID, file drops
+// overwrite a file named after the report, and manual receipts carry it as
+// their reference so report-service records them once.
+type DepartmentConnector interface {
+	Name() string
+	Deliver(ctx context.Context, report ReportEvent, dept DepartmentConfig) (DeliveryReceipt, error)
+}
+
+type DeliveryReceipt struct {
+	Department  string    `json:"department"`
+	Connector   string    `json:"connector"`
+	Status      string    `json:"status"`
+	Reference   string    `json:"reference,omitempty"`
+	Detail      string    `json:"detail,omitempty"`
+	Attempts    int       `json:"attempts"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+type DepartmentConfig struct {
+	Name      string          `json:"-"`
+	Connector string          `json:"connector"`
+	Timeout   string          `json:"timeout,omitempty"`
+	Retries   int             `json:"retries,omitempty"`
+	Webhook   *WebhookConfig  `json:"webhook,omitempty"`
+	SMTP      *SMTPConfig     `json:"smtp,omitempty"`
+	FileDrop  *FileDropConfig `json:"file_drop,omitempty"`
+}
+
+type connectorsFile struct {
+	Default     *DepartmentConfig           `json:"default,omitempty"`
+	Departments map[string]DepartmentConfig `json:"departments"`
+}
+
+var (
+	connectors = map[string]DepartmentConnector{
+		"webhook":   &webhookConnector{client: &http.Client{Transport: telemetry.NewTransport(nil)}},
+		"smtp":      &smtpConnector{},
+		"file_drop": &fileDropConnector{},
+		"manual":    &manualConnector{},
+	}
+
+	departmentConfigsMu sync.RWMutex
+	departmentConfigs   = connectorsFile{Departments: map[string]DepartmentConfig{}}
+
+	reportServiceURL = "http://localhost:8082"
+)
+
+func (c DepartmentConfig) timeout() time.Duration {
+	if d, err := time.ParseDuration(c.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return 10 * time.Second
+}
+
+// loadDepartmentConfigs reads DEPARTMENT_CONNECTORS_FILE (or inline JSON in
+// DEPARTMENT_CONNECTORS). ${VAR} references are expanded so secrets can stay
+// in the environment. Departments without an entry use "default", and
+// without that the manual connector.
+func loadDepartmentConfigs() error {
+	if v := strings.TrimSpace(os.Getenv("REPORT_SERVICE_URL")); v != "" {
+		reportServiceURL = strings.TrimRight(v, "/")
+	}
+
+	raw := os.Getenv("DEPARTMENT_CONNECTORS")
+	if path := strings.TrimSpace(os.Getenv("DEPARTMENT_CONNECTORS_FILE")); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+		raw = string(b)
+	}
+	if strings.TrimSpace(raw) == "" {
+		log.Println("⚠️ No department connectors configured; all departments use the manual connector")
+		return nil
+	}
+
+	var cfg connectorsFile
+	if err := json.Unmarshal([]byte(os.ExpandEnv(raw)), &cfg); err != nil {
+		return fmt.Errorf("parse department connectors: %w", err)
+	}
+
+	normalized := make(map[string]DepartmentConfig, len(cfg.Departments))
+	for name, dept := range cfg.Departments {
+		if _, ok := connectors[dept.Connector]; !ok {
+			return fmt.Errorf("department %q: unknown connector %q", name, dept.Connector)
+		}
+		normalized[strings.ToUpper(strings.TrimSpace(name))] = dept
+	}
+	if cfg.Default != nil {
+		if _, ok := connectors[cfg.Default.Connector]; !ok {
+			return fmt.Errorf("default: unknown connector %q", cfg.Default.Connector)
+		}
+	}
+	cfg.Departments = normalized
+
+	departmentConfigsMu.Lock()
+	departmentConfigs = cfg
+	departmentConfigsMu.Unlock()
+
+	log.Printf("✅ Loaded connector config for %d department(s)", len(normalized))
+	return nil
+}
+
+func departmentConfig(name string) DepartmentConfig {
+	departmentConfigsMu.RLock()
+	defer departmentConfigsMu.RUnlock()
+
+	if dept, ok := departmentConfigs.Departments[strings.ToUpper(strings.TrimSpace(name))]; ok {
+		dept.Name = name
+		return dept
+	}
+	if departmentConfigs.Default != nil {
+		dept := *departmentConfigs.Default
+		dept.Name = name
+		return dept
+	}
+	return DepartmentConfig{Name: name, Connector: "manual"}
+}
+
+// deliverToDepartment runs the department's connector with its timeout and
+// retry budget. Permanent errors (bad config, 4xx responses) are not retried
+// here; the queue-level retry in consumer.go still applies to the rest.
+func deliverToDepartment(ctx context.Context, report ReportEvent, dept DepartmentConfig) (DeliveryReceipt, error) {
+	connector := connectors[dept.Connector]
+	if connector == nil {
+		return DeliveryReceipt{}, permanent(fmt.Errorf("unknown connector %q for %s", dept.Connector, dept.Name))
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= dept.Retries+1; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, dept.timeout())
+		receipt, err := connector.Deliver(attemptCtx, report, dept)
+		cancel()
+
+		if err == nil {
+			receipt.Department = dept.Name
+			receipt.Connector = connector.Name()
+			receipt.Attempts = attempt
+			if receipt.Status == "" {
+				receipt.Status = ReceiptDelivered
+			}
+			if receipt.DeliveredAt.IsZero() {
+				receipt.DeliveredAt = time.Now()
+			}
+			return receipt, nil
+		}
+
+		lastErr = err
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			break
+		}
+		if attempt <= dept.Retries {
+			log.Printf("🔁 %s connector for %s failed (attempt %d/%d): %v", connector.Name(), dept.Name, attempt, dept.Retries+1, err)
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+	}
+	return DeliveryReceipt{}, lastErr
+}
+
+// reportDispatched tells report-service which department received the
+// report and through which connector, so it can store the receipt, move the
+// report to DISPATCHED and notify the citizen. Failing to record is logged
+// but does not fail the delivery, otherwise a retry would deliver the report
+// twice.
+func reportDispatched(ctx context.Context, reportID string, receipt DeliveryReceipt) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"report_id": reportID,
+		"receipt":   receipt,
+	})
+
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		if lastErr = postDispatched(ctx, body); lastErr == nil {
+			return
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+	log.Printf("⚠️ Failed to record dispatch of %s to %s: %v", reportID, receipt.Department, lastErr)
+}
+
+func postDispatched(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reportServiceURL+"/internal/reports/dispatched", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := middleware.SignInternalRequest(req, "report-service"); err != nil {
+		return err
+	}
+
+	resp, err := telemetry.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("report-service returned %d", resp.StatusCode)
+	default:
+		// 4xx will not succeed on retry; log and give up.
+		log.Printf("⚠️ report-service rejected dispatch record with code: %d", resp.StatusCode)
+		return nil
+	}
+}
+
+// decryptReportEvent turns the encrypted description and location of a
+// report.submitted event back into text for the departments. Fields that do
+// not decrypt, such as the plaintext in forwarded reports, are left as they
+// are.
+func decryptReportEvent(report *ReportEvent) {
+	if report.Description != "" {
+		if decrypted, err := security.DecryptString(report.Description); err == nil {
+			report.Description = decrypted
+		}
+	}
+	if report.Location != "" {
+		if decrypted, err := security.DecryptString(report.Location); err == nil {
+			report.Location = decrypted
+		}
+	}
+}
+
+func idempotencyKey(report ReportEvent, dept DepartmentConfig) string {
+	return report.ID + ":" + strings.ToLower(strings.ReplaceAll(dept.Name, " ", "_"))
+}
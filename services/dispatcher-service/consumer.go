@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/queue"
+	"citizen-reporting-system/pkg/telemetry"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	attemptHeader = "x-attempt"
+	// deliveredHeader lists the departments that already took the report,
+	// so a retry only goes to the ones that failed.
+	deliveredHeader = "x-delivered-to"
+)
+
+type consumerConfig struct {
+	Workers     int
+	Prefetch    int
+	MaxAttempts int
+	RetryBase   time.Duration
+	RetryMax    time.Duration
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent marks an error that retrying cannot fix, e.g. a malformed body.
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+func loadConsumerConfig() consumerConfig {
+	cfg := consumerConfig{
+		Workers:     envInt("DISPATCHER_WORKERS", 4),
+		MaxAttempts: envInt("DISPATCHER_MAX_ATTEMPTS", 5),
+		RetryBase:   envDuration("DISPATCHER_RETRY_BASE", 5*time.Second),
+		RetryMax:    envDuration("DISPATCHER_RETRY_MAX", 10*time.Minute),
+	}
+	cfg.Prefetch = envInt("DISPATCHER_PREFETCH", cfg.Workers*2)
+	return cfg
+}
+
+// retryDelay is the wait before delivery number attempt+1.
+func (c consumerConfig) retryDelay(attempt int) time.Duration {
+	d := c.RetryBase
+	for i := 1; i < attempt && d < c.RetryMax; i++ {
+		d *= 2
+	}
+	if d > c.RetryMax {
+		d = c.RetryMax
+	}
+	return d
+}
+
+// retryQueueName includes the TTL so changing the backoff settings declares
+// new queues instead of failing on mismatched arguments.
+func retryQueueName(delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", queueName, delay.Milliseconds())
+}
+
+// declareRetryQueues creates one delay queue per backoff step. Messages sit
+// there until their TTL expires and are then dead-lettered back onto
+// report_queue through the default exchange.
+func declareRetryQueues(cfg consumerConfig) queue.TopologyFunc {
+	return func(ch *amqp.Channel) error {
+		for attempt := 1; attempt < cfg.MaxAttempts; attempt++ {
+			delay := cfg.retryDelay(attempt)
+			err := queue.DeclareQueue(ch, retryQueueName(delay), amqp.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queueName,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func attemptsFrom(headers amqp.Table) int {
+	return headerInt(headers, attemptHeader)
+}
+
+// headerList splits a comma-separated header value.
+func headerList(headers amqp.Table, key string) []string {
+	var list []string
+	for _, v := range strings.Split(headerString(headers, key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func headerInt(headers amqp.Table, key string) int {
+	switch v := headers[key].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// runConsumers feeds deliveries to a fixed pool of workers. Prefetch bounds
+// how many unacknowledged messages the broker hands out, so a crash only
+// causes redelivery of in-flight reports.
+func runConsumers(cfg consumerConfig) {
+	jobs := make(chan amqp.Delivery)
+	for i := 0; i < cfg.Workers; i++ {
+		go func() {
+			for d := range jobs {
+				handleDelivery(cfg, d)
+			}
+		}()
+	}
+
+	broker.Consume(context.Background(), queue.ConsumerSpec{
+		Queue:    queueName,
+		Prefetch: cfg.Prefetch,
+	}, func(_ *amqp.Channel, d amqp.Delivery) {
+		jobs <- d
+	})
+}
+
+func handleDelivery(cfg consumerConfig, d amqp.Delivery) {
+	ctx, span := telemetry.StartConsumeSpan(d, queueName)
+	defer span.End()
+
+	routeTo := headerString(d.Headers, routeToHeader)
+	delivered := headerList(d.Headers, deliveredHeader)
+	err := processReport(ctx, d.Body, routeTo, delivered)
+	telemetry.RecordError(span, err)
+	if err == nil {
+		if ackErr := d.Ack(false); ackErr != nil {
+			log.Printf("⚠️ Failed to ack message: %v", ackErr)
+		}
+		return
+	}
+
+	attempt := attemptsFrom(d.Headers) + 1
+
+	var partial *partialRouteError
+	if errors.As(err, &partial) {
+		delivered = partial.Delivered
+	}
+
+	var perm *permanentError
+	if errors.As(err, &perm) || attempt >= cfg.MaxAttempts {
+		if dlqErr := moveToDLQ(ctx, d.Body, d.ContentType, d.MessageId, err.Error(), attempt, routeTo, delivered); dlqErr != nil {
+			_ = d.Nack(false, true)
+			return
+		}
+		_ = d.Ack(false)
+		return
+	}
+
+	delay := cfg.retryDelay(attempt)
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[attemptHeader] = int32(attempt)
+	headers["x-last-error"] = err.Error()
+	if len(delivered) > 0 {
+		headers[deliveredHeader] = strings.Join(delivered, ",")
+	}
+
+	pubErr := broker.Publish(ctx, "", retryQueueName(delay), false, amqp.Publishing{
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    d.MessageId,
+		Type:         d.Type,
+		Timestamp:    d.Timestamp,
+		Headers:      headers,
+		Body:         d.Body,
+	})
+	if pubErr != nil {
+		log.Printf("❌ Failed to schedule retry: %v", pubErr)
+		_ = d.Nack(false, true)
+		return
+	}
+
+	log.Printf("🔁 Attempt %d/%d failed, retrying in %s", attempt, cfg.MaxAttempts, delay)
+	_ = d.Ack(false)
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"citizen-reporting-system/pkg/events"
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+
+	"github.com/prometheus/client_golang/prometheus"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	routeToHeader   = "x-route-to"
+	dlqScanLimit    = 10000
+	dlqMetricsEvery = 30 * time.Second
+)
+
+var (
+	errDLQMessageNotFound = errors.New("dlq message not found")
+
+	// dlqMu serialises scans of the DLQ so two operations never hold the
+	// same unacked messages at once.
+	dlqMu sync.Mutex
+
+	dlqDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "dispatcher_dlq_depth",
+		Help: "Number of messages in the report dead-letter queue",
+	})
+	dlqOldestAge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "dispatcher_dlq_oldest_age_seconds",
+		Help: "Age in seconds of the oldest message in the report dead-letter queue",
+	})
+	dlqReplayed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "dispatcher_dlq_replayed_total",
+		Help: "Messages replayed from the dead-letter queue to report_queue",
+	})
+)
+
+type DLQMessage struct {
+	ID          string                 `json:"id"`
+	Reason      string                 `json:"reason"`
+	FailedAt    string                 `json:"failed_at,omitempty"`
+	Attempts    int                    `json:"attempts"`
+	RouteTo     string                 `json:"route_to,omitempty"`
+	DeliveredTo []string               `json:"delivered_to,omitempty"`
+	ReportID    string                 `json:"report_id,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Category    string                 `json:"category,omitempty"`
+	Size        int                    `json:"size"`
+	Redelivered bool                   `json:"redelivered"`
+	Headers     map[string]interface{} `json:"headers,omitempty"`
+	Payload     json.RawMessage        `json:"payload,omitempty"`
+}
+
+func registerDLQMetrics() {
+	prometheus.MustRegister(dlqDepth, dlqOldestAge, dlqReplayed)
+}
+
+func headerString(headers amqp.Table, key string) string {
+	if v, ok := headers[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// dlqMessageID prefers the MessageId set by moveToDLQ; messages parked before
+// IDs were assigned get a stable ID derived from their body and failure time.
+func dlqMessageID(d amqp.Delivery) string {
+	if d.MessageId != "" {
+		return d.MessageId
+	}
+	h := sha256.New()
+	h.Write(d.Body)
+	h.Write([]byte(headerString(d.Headers, "x-failed-at")))
+	return "legacy-" + hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func toDLQMessage(d amqp.Delivery, withPayload bool) DLQMessage {
+	msg := DLQMessage{
+		ID:          dlqMessageID(d),
+		Reason:      headerString(d.Headers, "x-exception-message"),
+		FailedAt:    headerString(d.Headers, "x-failed-at"),
+		Attempts:    attemptsFrom(d.Headers),
+		RouteTo:     headerString(d.Headers, routeToHeader),
+		DeliveredTo: headerList(d.Headers, deliveredHeader),
+		Size:        len(d.Body),
+		Redelivered: d.Redelivered,
+	}
+
+	var report ReportEvent
+	if env, err := events.Decode(d.Body, events.TypeReportSubmitted); err == nil && env.DataAs(events.TypeReportSubmitted, &report) == nil {
+		msg.ReportID = report.ID
+		msg.Title = report.Title
+		msg.Category = report.Category
+	}
+
+	if withPayload {
+		msg.Headers = map[string]interface{}(d.Headers)
+		if json.Valid(d.Body) {
+			msg.Payload = json.RawMessage(d.Body)
+		} else {
+			quoted, _ := json.Marshal(string(d.Body))
+			msg.Payload = quoted
+		}
+	}
+	return msg
+}
+
+// scanDLQ fetches up to limit messages without acknowledging them and hands
+// them to visit. Closing the channel afterwards returns every message that
+// visit did not ack to the queue in its original order.
+func scanDLQ(ctx context.Context, limit int, visit func(d amqp.Delivery) (stop bool, err error)) error {
+	dlqMu.Lock()
+	defer dlqMu.Unlock()
+
+	ch, err := broker.Channel(ctx)
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if limit <= 0 || limit > dlqScanLimit {
+		limit = dlqScanLimit
+	}
+
+	for i := 0; i < limit; i++ {
+		d, ok, err := ch.Get(dlqName, false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		stop, err := visit(d)
+		if err != nil {
+			return err
+		}
+		if stop {
+			return nil
+		}
+	}
+	return nil
+}
+
+func listDLQ(ctx context.Context, limit int) ([]DLQMessage, error) {
+	messages := make([]DLQMessage, 0)
+	err := scanDLQ(ctx, limit, func(d amqp.Delivery) (bool, error) {
+		messages = append(messages, toDLQMessage(d, false))
+		return false, nil
+	})
+	return messages, err
+}
+
+func getDLQMessage(ctx context.Context, id string) (*DLQMessage, error) {
+	var found *DLQMessage
+	err := scanDLQ(ctx, 0, func(d amqp.Delivery) (bool, error) {
+		if dlqMessageID(d) != id {
+			return false, nil
+		}
+		msg := toDLQMessage(d, true)
+		found = &msg
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, errDLQMessageNotFound
+	}
+	return found, nil
+}
+
+// replayDelivery republishes a parked message to report_queue with a fresh
+// attempt budget and only acks it off the DLQ once the broker confirms.
+func replayDelivery(ctx context.Context, d amqp.Delivery, routeTo, actor string) error {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	delete(headers, attemptHeader)
+	headers["x-replayed-at"] = time.Now().Format(time.RFC3339)
+	headers["x-replayed-by"] = actor
+	headers["x-replay-count"] = int32(headerInt(d.Headers, "x-replay-count") + 1)
+	if routeTo != "" {
+		headers[routeToHeader] = routeTo
+	}
+
+	contentType := d.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	err := broker.Publish(ctx, "", queueName, false, amqp.Publishing{
+		ContentType:  contentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    dlqMessageID(d),
+		Timestamp:    time.Now(),
+		Headers:      headers,
+		Body:         d.Body,
+	})
+	if err != nil {
+		return err
+	}
+	dlqReplayed.Inc()
+	return d.Ack(false)
+}
+
+func replayDLQMessage(ctx context.Context, id, routeTo, actor string) error {
+	replayed := false
+	err := scanDLQ(ctx, 0, func(d amqp.Delivery) (bool, error) {
+		if dlqMessageID(d) != id {
+			return false, nil
+		}
+		if err := replayDelivery(ctx, d, routeTo, actor); err != nil {
+			return true, err
+		}
+		replayed = true
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+	if !replayed {
+		return errDLQMessageNotFound
+	}
+	log.Printf("♻️ DLQ message %s replayed by %s", id, actor)
+	return nil
+}
+
+// replayAllDLQ replays the messages parked when it starts. A replayed
+// message that fails again fast is dead-lettered behind them, so the scan is
+// bounded by the depth read up front and stops at an ID it already replayed.
+func replayAllDLQ(ctx context.Context, routeTo, actor string) (int, error) {
+	depth, err := dlqDepthNow(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if depth == 0 {
+		return 0, nil
+	}
+
+	count := 0
+	replayed := make(map[string]bool, depth)
+	err = scanDLQ(ctx, depth, func(d amqp.Delivery) (bool, error) {
+		id := dlqMessageID(d)
+		if replayed[id] {
+			return true, nil
+		}
+		replayed[id] = true
+		if err := replayDelivery(ctx, d, routeTo, actor); err != nil {
+			return true, err
+		}
+		count++
+		return false, nil
+	})
+	log.Printf("♻️ %d DLQ message(s) replayed by %s", count, actor)
+	return count, err
+}
+
+func deleteDLQMessage(ctx context.Context, id, actor string) error {
+	deleted := false
+	err := scanDLQ(ctx, 0, func(d amqp.Delivery) (bool, error) {
+		if dlqMessageID(d) != id {
+			return false, nil
+		}
+		deleted = true
+		return true, d.Ack(false)
+	})
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errDLQMessageNotFound
+	}
+	log.Printf("🗑️ DLQ message %s purged by %s", id, actor)
+	return nil
+}
+
+func purgeDLQ(ctx context.Context, actor string) (int, error) {
+	ch, err := broker.Channel(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	count, err := ch.QueuePurge(dlqName, false)
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("🗑️ DLQ purged by %s (%d message(s))", actor, count)
+	return count, nil
+}
+
+// dlqDepthNow returns the number of messages ready in the DLQ.
+func dlqDepthNow(ctx context.Context) (int, error) {
+	ch, err := broker.Channel(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	q, err := ch.QueueDeclarePassive(dlqName, true, false, false, false, nil)
+	if err != nil {
+		return 0, err
+	}
+	return q.Messages, nil
+}
+
+func updateDLQMetrics() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	depth, err := dlqDepthNow(ctx)
+	if err != nil {
+		return
+	}
+	dlqDepth.Set(float64(depth))
+
+	if depth == 0 {
+		dlqOldestAge.Set(0)
+		return
+	}
+
+	_ = scanDLQ(ctx, 1, func(d amqp.Delivery) (bool, error) {
+		failedAt := d.Timestamp
+		if t, err := time.Parse(time.RFC3339, headerString(d.Headers, "x-failed-at")); err == nil {
+			failedAt = t
+		}
+		if !failedAt.IsZero() {
+			dlqOldestAge.Set(time.Since(failedAt).Seconds())
+		}
+		return true, nil
+	})
+}
+
+func startDLQMetricsWorker() {
+	ticker := time.NewTicker(dlqMetricsEvery)
+	defer ticker.Stop()
+
+	for {
+		if broker.Health().Connected {
+			updateDLQMetrics()
+		}
+		<-ticker.C
+	}
+}
+
+func dlqActor(r *http.Request) string {
+	if claims, ok := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims); ok && claims != nil {
+		return claims.UserID
+	}
+	return "unknown"
+}
+
+func writeDLQError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errDLQMessageNotFound) {
+		response.Error(w, http.StatusNotFound, "DLQ message not found", "")
+		return
+	}
+	response.Error(w, http.StatusBadGateway, "DLQ operation failed", err.Error())
+}
+
+type replayRequest struct {
+	RouteTo string `json:"route_to"`
+}
+
+func readReplayRequest(r *http.Request) replayRequest {
+	var req replayRequest
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+	}
+	req.RouteTo = strings.TrimSpace(req.RouteTo)
+	return req
+}
+
+// adminDLQHandler serves:
+//
+//	GET    /api/dispatcher/dlq              list parked messages
+//	DELETE /api/dispatcher/dlq              purge the whole DLQ
+//	POST   /api/dispatcher/dlq/replay       replay every message
+//	GET    /api/dispatcher/dlq/{id}         inspect one message
+//	POST   /api/dispatcher/dlq/{id}/replay  replay one message
+//	DELETE /api/dispatcher/dlq/{id}         discard one message
+func adminDLQHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/dispatcher/dlq"), "/")
+	parts := strings.Split(path, "/")
+	actor := dlqActor(r)
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit <= 0 {
+			limit = 100
+		}
+		messages, err := listDLQ(ctx, limit)
+		if err != nil {
+			writeDLQError(w, err)
+			return
+		}
+		response.Success(w, http.StatusOK, "DLQ messages fetched successfully", map[string]interface{}{
+			"messages": messages,
+			"count":    len(messages),
+		})
+
+	case path == "" && r.Method == http.MethodDelete:
+		count, err := purgeDLQ(ctx, actor)
+		if err != nil {
+			writeDLQError(w, err)
+			return
+		}
+		response.Success(w, http.StatusOK, "DLQ purged", map[string]interface{}{"purged": count})
+
+	case path == "replay" && r.Method == http.MethodPost:
+		req := readReplayRequest(r)
+		count, err := replayAllDLQ(ctx, req.RouteTo, actor)
+		if err != nil {
+			writeDLQError(w, err)
+			return
+		}
+		response.Success(w, http.StatusOK, "DLQ messages replayed", map[string]interface{}{"replayed": count})
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		msg, err := getDLQMessage(ctx, parts[0])
+		if err != nil {
+			writeDLQError(w, err)
+			return
+		}
+		response.Success(w, http.StatusOK, "DLQ message fetched successfully", msg)
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if err := deleteDLQMessage(ctx, parts[0], actor); err != nil {
+			writeDLQError(w, err)
+			return
+		}
+		response.Success(w, http.StatusOK, "DLQ message purged", map[string]interface{}{"id": parts[0]})
+
+	case len(parts) == 2 && parts[1] == "replay" && r.Method == http.MethodPost:
+		req := readReplayRequest(r)
+		if err := replayDLQMessage(ctx, parts[0], req.RouteTo, actor); err != nil {
+			writeDLQError(w, err)
+			return
+		}
+		response.Success(w, http.StatusOK, "DLQ message replayed", map[string]interface{}{
+			"id":       parts[0],
+			"route_to": req.RouteTo,
+		})
+
+	default:
+		response.Error(w, http.StatusNotFound, "Not found", "")
+	}
+}
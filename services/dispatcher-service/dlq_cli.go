@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const dlqUsage = `usage: dispatcher-service dlq <command>
+
+commands:
+  list [-limit N]               list parked messages with reason and time
+  show <id>                     print one message with headers and payload
+  replay <id> [-route-to DEPT]  replay one message to report_queue
+  replay-all [-route-to DEPT]   replay every message to report_queue
+  delete <id>                   discard one message
+  purge                         discard every message`
+
+// runDLQCommand implements `dispatcher-service dlq ...` for operators without
+// access to the admin API. It returns the process exit code.
+func runDLQCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, dlqUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("dlq "+args[0], flag.ContinueOnError)
+	limit := fs.Int("limit", 100, "maximum messages to list")
+	routeTo := fs.String("route-to", "", "override the routing target on replay")
+	rest := args[1:]
+	id := ""
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "-") {
+		id, rest = rest[0], rest[1:]
+	}
+	if err := fs.Parse(rest); err != nil {
+		return 2
+	}
+	if id == "" {
+		id = fs.Arg(0)
+	}
+	needsID := args[0] == "show" || args[0] == "replay" || args[0] == "delete"
+	if needsID && id == "" {
+		fmt.Fprintln(os.Stderr, dlqUsage)
+		return 2
+	}
+
+	broker.Start()
+	defer broker.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	actor := "cli"
+	if u := os.Getenv("USER"); u != "" {
+		actor = "cli:" + u
+	}
+
+	var (
+		out interface{}
+		err error
+	)
+	switch args[0] {
+	case "list":
+		out, err = listDLQ(ctx, *limit)
+	case "show":
+		out, err = getDLQMessage(ctx, id)
+	case "replay":
+		err = replayDLQMessage(ctx, id, *routeTo, actor)
+		out = map[string]string{"replayed": id}
+	case "replay-all":
+		var n int
+		n, err = replayAllDLQ(ctx, *routeTo, actor)
+		out = map[string]int{"replayed": n}
+	case "delete":
+		err = deleteDLQMessage(ctx, id, actor)
+		out = map[string]string{"deleted": id}
+	case "purge":
+		var n int
+		n, err = purgeDLQ(ctx, actor)
+		out = map[string]int{"purged": n}
+	default:
+		fmt.Fprintln(os.Stderr, dlqUsage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dlq %s failed: %v\n", args[0], err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(out)
+	return 0
+}
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	"citizen-reporting-system/pkg/response"
+)
+
+// RuleSet is the routing configuration. Rules are evaluated in order; the
+// first enabled rule whose conditions all match decides the departments,
+// unless it sets "continue", in which case later matches are added too.
+type RuleSet struct {
+	Version  string   `json:"version"`
+	Rules    []Rule   `json:"rules"`
+	Default  []string `json:"default"`
+	Fallback []string `json:"fallback,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+	LoadedAt string   `json:"loaded_at,omitempty"`
+	Source   string   `json:"source,omitempty"`
+	location *time.Location
+}
+
+type Rule struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description,omitempty"`
+	Disabled    bool      `json:"disabled,omitempty"`
+	Match       RuleMatch `json:"match"`
+	Departments []string  `json:"departments"`
+	Fallback    []string  `json:"fallback,omitempty"`
+	Continue    bool      `json:"continue,omitempty"`
+}
+
+// RuleMatch conditions are ANDed; values inside one condition are ORed and
+// compared case-insensitively. An empty condition always matches.
+type RuleMatch struct {
+	Categories    []string   `json:"categories,omitempty"`
+	Subcategories []string   `json:"subcategories,omitempty"`
+	Regions       []string   `json:"regions,omitempty"`
+	Keywords      []string   `json:"keywords,omitempty"`
+	Priorities    []string   `json:"priorities,omitempty"`
+	TimeOfDay     *TimeRange `json:"time_of_day,omitempty"`
+}
+
+// TimeRange is "HH:MM" to "HH:MM" in the rule set's timezone; a range whose
+// end is before its start wraps past midnight.
+type TimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type RouteDecision struct {
+	Version     string       `json:"version"`
+	RuleIDs     []string     `json:"rule_ids"`
+	Departments []string     `json:"departments"`
+	Fallback    []string     `json:"fallback,omitempty"`
+	EvaluatedAt time.Time    `json:"evaluated_at"`
+	Trace       []RuleResult `json:"trace,omitempty"`
+}
+
+type RuleResult struct {
+	RuleID  string `json:"rule_id"`
+	Matched bool   `json:"matched"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+var (
+	routerMu     sync.RWMutex
+	activeRules  *RuleSet
+	rulesModTime time.Time
+)
+
+func defaultRuleSet() *RuleSet {
+	return &RuleSet{
+		Version: "builtin",
+		Rules: []Rule{
+			{ID: "sampah", Match: RuleMatch{Categories: []string{"Sampah"}}, Departments: []string{"DINAS KEBERSIHAN"}},
+			{ID: "jalan", Match: RuleMatch{Categories: []string{"Jalan"}}, Departments: []string{"DINAS PU (PEKERJAAN UMUM)"}},
+			{ID: "keamanan", Match: RuleMatch{Categories: []string{"Keamanan"}}, Departments: []string{"KEPOLISIAN / SATPOL PP"}},
+		},
+		Default: []string{"PEMDA PUSAT (KATEGORI UMUM)"},
+	}
+}
+
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(v))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (rs *RuleSet) validate() error {
+	if len(rs.Default) == 0 {
+		return fmt.Errorf("default departments are required")
+	}
+
+	tz := rs.Timezone
+	if tz == "" {
+		tz = os.Getenv("ROUTING_TIMEZONE")
+	}
+	if tz == "" {
+		tz = "Asia/Jakarta"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return fmt.Errorf("timezone %q: %w", tz, err)
+	}
+	rs.Timezone = tz
+	rs.location = loc
+
+	seen := map[string]bool{}
+	for i, rule := range rs.Rules {
+		if rule.ID == "" {
+			return fmt.Errorf("rule %d: id is required", i)
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("rule %q: duplicate id", rule.ID)
+		}
+		seen[rule.ID] = true
+		if len(rule.Departments) == 0 {
+			return fmt.Errorf("rule %q: at least one department is required", rule.ID)
+		}
+		if tr := rule.Match.TimeOfDay; tr != nil {
+			if _, err := parseClock(tr.From); err != nil {
+				return fmt.Errorf("rule %q: invalid time_of_day.from: %w", rule.ID, err)
+			}
+			if _, err := parseClock(tr.To); err != nil {
+				return fmt.Errorf("rule %q: invalid time_of_day.to: %w", rule.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func rulesFile() string {
+	return strings.TrimSpace(os.Getenv("ROUTING_RULES_FILE"))
+}
+
+// loadRoutingRules (re)reads ROUTING_RULES_FILE. An invalid file is rejected
+// and the previously active rules stay in force.
+func loadRoutingRules() error {
+	path := rulesFile()
+	if path == "" {
+		rs := defaultRuleSet()
+		if err := rs.validate(); err != nil {
+			return err
+		}
+		rs.Source = "builtin"
+		rs.LoadedAt = time.Now().Format(time.RFC3339)
+		setRuleSet(rs, time.Time{})
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var rs RuleSet
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := rs.validate(); err != nil {
+		return fmt.Errorf("invalid rules in %s: %w", path, err)
+	}
+	rs.Source = path
+	rs.LoadedAt = time.Now().Format(time.RFC3339)
+
+	setRuleSet(&rs, info.ModTime())
+	log.Printf("✅ Routing rules version %s loaded (%d rules)", rs.Version, len(rs.Rules))
+	return nil
+}
+
+func setRuleSet(rs *RuleSet, modTime time.Time) {
+	routerMu.Lock()
+	activeRules = rs
+	rulesModTime = modTime
+	routerMu.Unlock()
+}
+
+func currentRules() *RuleSet {
+	routerMu.RLock()
+	defer routerMu.RUnlock()
+	return activeRules
+}
+
+// watchRoutingRules hot-reloads the rules file when its modification time
+// changes.
+func watchRoutingRules() {
+	path := rulesFile()
+	if path == "" {
+		return
+	}
+	interval := envDuration("ROUTING_RELOAD_INTERVAL", 10*time.Second)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		routerMu.RLock()
+		changed := !info.ModTime().Equal(rulesModTime)
+		routerMu.RUnlock()
+		if !changed {
+			continue
+		}
+		if err := loadRoutingRules(); err != nil {
+			log.Printf("⚠️ Routing rules reload failed, keeping previous version: %v", err)
+			routerMu.Lock()
+			rulesModTime = info.ModTime()
+			routerMu.Unlock()
+		}
+	}
+}
+
+func containsFold(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m RuleMatch) evaluate(report ReportEvent, at time.Time) (bool, string) {
+	if len(m.Categories) > 0 && !containsFold(m.Categories, report.Category) {
+		return false, "category " + report.Category
+	}
+	if len(m.Subcategories) > 0 && !containsFold(m.Subcategories, report.Subcategory) {
+		return false, "subcategory " + report.Subcategory
+	}
+	if len(m.Regions) > 0 {
+		matched := false
+		for _, region := range m.Regions {
+			// Region codes are hierarchical, so "3171" also matches "3171.01".
+			if report.Region != "" && strings.HasPrefix(strings.ToLower(report.Region), strings.ToLower(region)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, "region " + report.Region
+		}
+	}
+	if len(m.Keywords) > 0 {
+		title := strings.ToLower(report.Title)
+		matched := false
+		for _, kw := range m.Keywords {
+			if kw != "" && strings.Contains(title, strings.ToLower(kw)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, "no keyword in title"
+		}
+	}
+	if len(m.Priorities) > 0 && !containsFold(m.Priorities, report.Priority) {
+		return false, "priority " + report.Priority
+	}
+	if tr := m.TimeOfDay; tr != nil {
+		from, _ := parseClock(tr.From)
+		to, _ := parseClock(tr.To)
+		now := at.Hour()*60 + at.Minute()
+		in := now >= from && now < to
+		if to <= from {
+			in = now >= from || now < to
+		}
+		if !in {
+			return false, "outside " + tr.From + "-" + tr.To
+		}
+	}
+	return true, ""
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !containsFold(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func (rs *RuleSet) Resolve(report ReportEvent, at time.Time, withTrace bool) RouteDecision {
+	local := at.In(rs.location)
+	decision := RouteDecision{Version: rs.Version, EvaluatedAt: at}
+
+	for _, rule := range rs.Rules {
+		if rule.Disabled {
+			if withTrace {
+				decision.Trace = append(decision.Trace, RuleResult{RuleID: rule.ID, Reason: "disabled"})
+			}
+			continue
+		}
+		matched, reason := rule.Match.evaluate(report, local)
+		if withTrace {
+			decision.Trace = append(decision.Trace, RuleResult{RuleID: rule.ID, Matched: matched, Reason: reason})
+		}
+		if !matched {
+			continue
+		}
+		decision.RuleIDs = append(decision.RuleIDs, rule.ID)
+		decision.Departments = appendUnique(decision.Departments, rule.Departments...)
+		decision.Fallback = appendUnique(decision.Fallback, rule.Fallback...)
+		if !rule.Continue {
+			break
+		}
+	}
+
+	if len(decision.Departments) == 0 {
+		decision.RuleIDs = []string{"default"}
+		decision.Departments = append([]string(nil), rs.Default...)
+	}
+	decision.Fallback = appendUnique(decision.Fallback, rs.Fallback...)
+	return decision
+}
+
+// partialRouteError reports departments that failed; Delivered holds the
+// ones that took the report, including those from earlier attempts.
+type partialRouteError struct {
+	Delivered []string
+	err       error
+}
+
+func (e *partialRouteError) Error() string { return e.err.Error() }
+func (e *partialRouteError) Unwrap() error { return e.err }
+
+// routeFailures holds one error per department that could not be covered.
+type routeFailures []error
+
+func (f routeFailures) Error() string {
+	parts := make([]string, len(f))
+	for i, err := range f {
+		parts[i] = err.Error()
+	}
+	return "routing failed for " + strings.Join(parts, "; ")
+}
+
+func (f routeFailures) Unwrap() []error { return f }
+
+// routeReport delivers to every department in the decision that is not in
+// delivered. A department that fails is covered by the first fallback that
+// has or accepts the report; the fallback, not the failed department, is
+// then recorded as delivered. Each fallback is tried at most once per pass.
+func routeReport(ctx context.Context, report ReportEvent, decision RouteDecision, delivered []string) error {
+	log.Printf("🧭 Rules %s (version %s) -> %v", strings.Join(decision.RuleIDs, ","), decision.Version, decision.Departments)
+
+	delivered = append([]string(nil), delivered...)
+	fallbackFailed := map[string]bool{}
+	var failed routeFailures
+	for _, dept := range decision.Departments {
+		if containsFold(delivered, dept) {
+			log.Printf("⏭️ %s already has report %s, skipping", dept, report.ID)
+			continue
+		}
+		_, err := dispatchToDepartment(ctx, report, dept)
+		if err == nil {
+			delivered = append(delivered, dept)
+			continue
+		}
+		log.Printf("❌ Delivery to %s failed: %v", dept, err)
+
+		covered := false
+		for _, fb := range decision.Fallback {
+			if containsFold(decision.Departments, fb) || fallbackFailed[strings.ToLower(fb)] {
+				continue
+			}
+			if containsFold(delivered, fb) {
+				log.Printf("↪️ Fallback %s already has report %s, covering %s", fb, report.ID, dept)
+				covered = true
+				break
+			}
+			if _, fbErr := dispatchToDepartment(ctx, report, fb); fbErr != nil {
+				log.Printf("❌ Fallback %s failed: %v", fb, fbErr)
+				fallbackFailed[strings.ToLower(fb)] = true
+				continue
+			}
+			log.Printf("↪️ Fallback %s accepted report for %s", fb, dept)
+			delivered = append(delivered, fb)
+			covered = true
+			break
+		}
+		if !covered {
+			failed = append(failed, fmt.Errorf("%s: %w", dept, err))
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	// Only when no failed department could take the report on a retry is the
+	// failure permanent. Otherwise just the text is kept, so a permanent
+	// failure does not stop the retry the others need.
+	allPermanent := true
+	for _, f := range failed {
+		var perm *permanentError
+		if !errors.As(f, &perm) {
+			allPermanent = false
+			break
+		}
+	}
+	err := errors.New(failed.Error())
+	if allPermanent {
+		err = permanent(failed)
+	}
+	return &partialRouteError{Delivered: delivered, err: err}
+}
+
+func adminRoutingRulesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		response.Success(w, http.StatusOK, "Routing rules fetched successfully", currentRules())
+	case http.MethodPost:
+		if err := loadRoutingRules(); err != nil {
+			response.Error(w, http.StatusUnprocessableEntity, "Failed to reload routing rules", err.Error())
+			return
+		}
+		log.Printf("♻️ Routing rules reloaded by %s", dlqActor(r))
+		response.Success(w, http.StatusOK, "Routing rules reloaded", currentRules())
+	default:
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// adminRoutingDryRunHandler shows which rule would fire for a report without
+// delivering anything. "at" optionally evaluates time-of-day rules at another
+// moment.
+func adminRoutingDryRunHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	var input struct {
+		ReportEvent
+		At string `json:"at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	at := time.Now()
+	if input.At != "" {
+		t, err := time.Parse(time.RFC3339, input.At)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid at", err.Error())
+			return
+		}
+		at = t
+	}
+
+	rs := currentRules()
+	decision := rs.Resolve(input.ReportEvent, at, true)
+	response.Success(w, http.StatusOK, "Routing dry run", decision)
+}
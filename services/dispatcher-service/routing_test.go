@@ -0,0 +1,170 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testRuleSet(t *testing.T, rules ...Rule) *RuleSet {
+	t.Helper()
+	rs := &RuleSet{
+		Version:  "test",
+		Rules:    rules,
+		Default:  []string{"general"},
+		Fallback: []string{"pemda"},
+		Timezone: "Asia/Jakarta",
+	}
+	if err := rs.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	return rs
+}
+
+func TestRuleMatchEvaluate(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(hh, mm int) time.Time { return time.Date(2025, 3, 10, hh, mm, 0, 0, jakarta) }
+	report := ReportEvent{
+		Title:       "Lampu jalan mati di perempatan",
+		Category:    "Lampu Jalan",
+		Subcategory: "Mati Total",
+		Region:      "3171.01",
+		Priority:    "HIGH",
+	}
+
+	tests := []struct {
+		name  string
+		match RuleMatch
+		at    time.Time
+		want  bool
+	}{
+		{"empty match", RuleMatch{}, at(12, 0), true},
+		{"category case-insensitive", RuleMatch{Categories: []string{"lampu jalan"}}, at(12, 0), true},
+		{"category mismatch", RuleMatch{Categories: []string{"Sampah"}}, at(12, 0), false},
+		{"subcategory", RuleMatch{Subcategories: []string{"MATI TOTAL"}}, at(12, 0), true},
+		{"region prefix", RuleMatch{Regions: []string{"3171"}}, at(12, 0), true},
+		{"region other city", RuleMatch{Regions: []string{"3172"}}, at(12, 0), false},
+		{"region longer than report", RuleMatch{Regions: []string{"3171.01.002"}}, at(12, 0), false},
+		{"keyword in title", RuleMatch{Keywords: []string{"PEREMPATAN"}}, at(12, 0), true},
+		{"keyword missing", RuleMatch{Keywords: []string{"banjir"}}, at(12, 0), false},
+		{"priority", RuleMatch{Priorities: []string{"low", "high"}}, at(12, 0), true},
+		{"priority mismatch", RuleMatch{Priorities: []string{"low"}}, at(12, 0), false},
+		{"conditions are ANDed", RuleMatch{Categories: []string{"Lampu Jalan"}, Priorities: []string{"low"}}, at(12, 0), false},
+		{"inside daytime range", RuleMatch{TimeOfDay: &TimeRange{From: "08:00", To: "17:00"}}, at(8, 0), true},
+		{"daytime range end is exclusive", RuleMatch{TimeOfDay: &TimeRange{From: "08:00", To: "17:00"}}, at(17, 0), false},
+		{"overnight range before midnight", RuleMatch{TimeOfDay: &TimeRange{From: "22:00", To: "06:00"}}, at(23, 30), true},
+		{"overnight range after midnight", RuleMatch{TimeOfDay: &TimeRange{From: "22:00", To: "06:00"}}, at(5, 59), true},
+		{"outside overnight range", RuleMatch{TimeOfDay: &TimeRange{From: "22:00", To: "06:00"}}, at(12, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := tt.match.evaluate(report, tt.at)
+			if got != tt.want {
+				t.Errorf("evaluate() = %v (%s), want %v", got, reason, tt.want)
+			}
+			if !got && reason == "" {
+				t.Error("a non-match should give a reason")
+			}
+		})
+	}
+}
+
+func TestRuleSetResolvePrecedence(t *testing.T) {
+	sampah := Rule{ID: "sampah", Match: RuleMatch{Categories: []string{"Sampah"}}, Departments: []string{"kebersihan"}}
+	sampahUrgent := Rule{ID: "sampah-urgent", Match: RuleMatch{Categories: []string{"Sampah"}, Priorities: []string{"HIGH"}}, Departments: []string{"lingkungan_hidup"}, Fallback: []string{"satpol"}}
+	anyHigh := Rule{ID: "any-high", Match: RuleMatch{Priorities: []string{"HIGH"}}, Departments: []string{"kebersihan", "pemda"}}
+
+	withContinue := sampahUrgent
+	withContinue.Continue = true
+	disabled := sampahUrgent
+	disabled.Disabled = true
+
+	tests := []struct {
+		name         string
+		rules        []Rule
+		report       ReportEvent
+		wantRules    []string
+		wantDepts    []string
+		wantFallback []string
+	}{
+		{
+			name:         "first match wins",
+			rules:        []Rule{sampahUrgent, sampah},
+			report:       ReportEvent{Category: "Sampah", Priority: "HIGH"},
+			wantRules:    []string{"sampah-urgent"},
+			wantDepts:    []string{"lingkungan_hidup"},
+			wantFallback: []string{"satpol", "pemda"},
+		},
+		{
+			name:         "order decides, not specificity",
+			rules:        []Rule{sampah, sampahUrgent},
+			report:       ReportEvent{Category: "Sampah", Priority: "HIGH"},
+			wantRules:    []string{"sampah"},
+			wantDepts:    []string{"kebersihan"},
+			wantFallback: []string{"pemda"},
+		},
+		{
+			name:         "continue adds later matches without duplicates",
+			rules:        []Rule{withContinue, anyHigh, sampah},
+			report:       ReportEvent{Category: "Sampah", Priority: "HIGH"},
+			wantRules:    []string{"sampah-urgent", "any-high"},
+			wantDepts:    []string{"lingkungan_hidup", "kebersihan", "pemda"},
+			wantFallback: []string{"satpol", "pemda"},
+		},
+		{
+			name:         "disabled rule is skipped",
+			rules:        []Rule{disabled, sampah},
+			report:       ReportEvent{Category: "Sampah", Priority: "HIGH"},
+			wantRules:    []string{"sampah"},
+			wantDepts:    []string{"kebersihan"},
+			wantFallback: []string{"pemda"},
+		},
+		{
+			name:         "no match uses default",
+			rules:        []Rule{sampah, sampahUrgent},
+			report:       ReportEvent{Category: "Jalan", Priority: "LOW"},
+			wantRules:    []string{"default"},
+			wantDepts:    []string{"general"},
+			wantFallback: []string{"pemda"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := testRuleSet(t, tt.rules...)
+			got := rs.Resolve(tt.report, time.Now(), false)
+			if !reflect.DeepEqual(got.RuleIDs, tt.wantRules) {
+				t.Errorf("RuleIDs = %v, want %v", got.RuleIDs, tt.wantRules)
+			}
+			if !reflect.DeepEqual(got.Departments, tt.wantDepts) {
+				t.Errorf("Departments = %v, want %v", got.Departments, tt.wantDepts)
+			}
+			if !reflect.DeepEqual(got.Fallback, tt.wantFallback) {
+				t.Errorf("Fallback = %v, want %v", got.Fallback, tt.wantFallback)
+			}
+			if got.Trace != nil {
+				t.Errorf("Trace = %v, want none without withTrace", got.Trace)
+			}
+		})
+	}
+}
+
+func TestRuleSetResolveTrace(t *testing.T) {
+	disabled := Rule{ID: "off", Disabled: true, Match: RuleMatch{}, Departments: []string{"x"}}
+	miss := Rule{ID: "miss", Match: RuleMatch{Categories: []string{"Jalan"}}, Departments: []string{"pu"}}
+	hit := Rule{ID: "hit", Match: RuleMatch{}, Departments: []string{"general"}}
+	after := Rule{ID: "after", Match: RuleMatch{}, Departments: []string{"never"}}
+
+	got := testRuleSet(t, disabled, miss, hit, after).Resolve(ReportEvent{Category: "Sampah"}, time.Now(), true)
+
+	want := []RuleResult{
+		{RuleID: "off", Reason: "disabled"},
+		{RuleID: "miss", Reason: "category Sampah"},
+		{RuleID: "hit", Matched: true},
+	}
+	if !reflect.DeepEqual(got.Trace, want) {
+		t.Errorf("Trace = %+v, want %+v", got.Trace, want)
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/services/notification-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	adminDigestType         = "admin_digest"
+	adminDigestCollection   = "admin_digest_buckets"
+	adminDigestLease        = 2 * time.Minute
+	adminDigestPollInterval = 30 * time.Second
+	adminDigestRetention    = 7 * 24 * time.Hour
+)
+
+var (
+	// adminDigestTypes are batched per department instead of being pushed
+	// and delivered one by one. They are still stored individually, so the
+	// inbox keeps the per-event stream.
+	adminDigestTypes = map[string]bool{
+		"new_report": true,
+		"escalation": true,
+	}
+
+	// adminDigestDepartments are the departments category-addressed
+	// notifications are grouped under; anything else goes to "general".
+	adminDigestDepartments = []string{"kebersihan", "pekerjaan_umum", "penerangan_jalan", "lingkungan_hidup", "perhubungan"}
+
+	adminDigestWindow   = loadAdminDigestWindow()
+	adminDigestMaxItems = envInt("ADMIN_DIGEST_MAX_ITEMS", digestMaxItems)
+)
+
+// loadAdminDigestWindow reads ADMIN_DIGEST_WINDOW; "0" or "off" turns
+// batching off.
+func loadAdminDigestWindow() time.Duration {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("ADMIN_DIGEST_WINDOW"))) {
+	case "0", "off":
+		return 0
+	}
+	return envDuration("ADMIN_DIGEST_WINDOW", 10*time.Minute)
+}
+
+// batchedForAdminDigest reports whether n reaches admins through the
+// department digest rather than on its own.
+func batchedForAdminDigest(n models.Notification) bool {
+	return adminDigestWindow > 0 && n.Audience == models.AudienceAdmin && adminDigestTypes[n.Type]
+}
+
+func ensureAdminDigestIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(adminDigestCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "flushed", Value: 1}, {Key: "flush_at", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "flushed_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(adminDigestRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+// notificationDepartments lists the departments an admin notification
+// belongs to: those it is addressed to, or those covering its category.
+func notificationDepartments(n models.Notification) []string {
+	var out []string
+	for _, d := range n.Departments {
+		if key := canonicalDepartment(d); key != "" && !containsString(out, key) {
+			out = append(out, key)
+		}
+	}
+	if len(out) > 0 {
+		return out
+	}
+	for _, d := range adminDigestDepartments {
+		if containsString(mapDepartmentToCategories(d), n.Category) {
+			out = append(out, d)
+		}
+	}
+	if len(out) == 0 {
+		out = []string{"general"}
+	}
+	return out
+}
+
+// adminDigestMaxSkip bounds how many closed windows a notification skips
+// past looking for an open bucket.
+const adminDigestMaxSkip = 100
+
+// addToAdminDigest counts n in the bucket of each of its departments for
+// the window it was created in. Windows are aligned to the clock, so every
+// instance fills the same bucket. A bucket the worker has claimed or flushed
+// is closed; n then goes to the next window's bucket instead of being lost.
+// A redelivered notification is found where it was counted before.
+func addToAdminDigest(ctx context.Context, n models.Notification) error {
+	created := n.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+	for _, dept := range notificationDepartments(n) {
+		start := created.Truncate(adminDigestWindow)
+		for skipped := 0; ; skipped++ {
+			if skipped >= adminDigestMaxSkip {
+				return fmt.Errorf("no open digest bucket for %s after %d windows", dept, skipped)
+			}
+			added, err := addToAdminDigestBucket(ctx, dept, start, n)
+			if err != nil {
+				return err
+			}
+			if added {
+				break
+			}
+			start = start.Add(adminDigestWindow)
+		}
+	}
+	return nil
+}
+
+// addToAdminDigestBucket counts n in dept's bucket for the window starting at
+// start. It reports false when that bucket is closed and does not list n.
+func addToAdminDigestBucket(ctx context.Context, dept string, start time.Time, n models.Notification) (bool, error) {
+	id := fmt.Sprintf("%s:%d", dept, start.Unix())
+	_, err := db.Collection(adminDigestCollection).UpdateOne(ctx,
+		bson.M{
+			"_id":              id,
+			"flushed":          false,
+			"locked_until":     bson.M{"$exists": false},
+			"notification_ids": bson.M{"$ne": n.ID},
+		},
+		bson.M{
+			"$setOnInsert": bson.M{
+				"department":   dept,
+				"window_start": start,
+				"flush_at":     start.Add(adminDigestWindow),
+			},
+			"$addToSet": bson.M{"notification_ids": n.ID},
+			"$inc":      bson.M{"total": 1, "counts." + n.Type: 1},
+			"$push": bson.M{"items": bson.M{
+				"$each":  []models.DigestItem{digestItem(n)},
+				"$sort":  bson.M{"created_at": -1},
+				"$slice": adminDigestMaxItems,
+			}},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err == nil {
+		return true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return false, err
+	}
+
+	// The upsert collided with an existing bucket: either n is in it already
+	// or the bucket is closed.
+	count, err := db.Collection(adminDigestCollection).CountDocuments(ctx, bson.M{"_id": id, "notification_ids": n.ID})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func startAdminDigestWorker() {
+	if adminDigestWindow <= 0 {
+		log.Println("[INFO] Admin digest batching disabled")
+		return
+	}
+	ticker := time.NewTicker(adminDigestPollInterval)
+	defer ticker.Stop()
+
+	log.Printf("[INFO] Admin digest worker started (window %s)", adminDigestWindow)
+
+	for range ticker.C {
+		for i := 0; i < 50; i++ {
+			if !flushNextAdminDigest() {
+				break
+			}
+		}
+	}
+}
+
+// flushNextAdminDigest claims one bucket whose window has closed and turns
+// it into the department's digest notification.
+func flushNextAdminDigest() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), adminDigestLease)
+	defer cancel()
+
+	now := time.Now()
+	var b models.AdminDigestBucket
+	err := db.Collection(adminDigestCollection).FindOneAndUpdate(ctx,
+		bson.M{
+			"flushed":  false,
+			"flush_at": bson.M{"$lte": now},
+			"$or": []bson.M{
+				{"locked_until": bson.M{"$exists": false}},
+				{"locked_until": bson.M{"$lte": now}},
+			},
+		},
+		bson.M{"$set": bson.M{"locked_until": now.Add(adminDigestLease)}},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "flush_at", Value: 1}}),
+	).Decode(&b)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("[ERROR] Admin digest: failed to claim bucket: %v", err)
+		}
+		return false
+	}
+
+	if err := sendAdminDigest(ctx, b); err != nil {
+		// The lease expires and the bucket is retried; the digest is keyed
+		// on the bucket, so it is stored once.
+		log.Printf("[WARN] Admin digest %s failed: %v", b.ID, err)
+		return true
+	}
+
+	_, err = db.Collection(adminDigestCollection).UpdateOne(ctx, bson.M{"_id": b.ID}, bson.M{
+		"$set":   bson.M{"flushed": true, "flushed_at": now},
+		"$unset": bson.M{"locked_until": ""},
+	})
+	if err != nil {
+		log.Printf("[ERROR] Admin digest: failed to mark bucket %s flushed: %v", b.ID, err)
+	}
+	return true
+}
+
+func sendAdminDigest(ctx context.Context, b models.AdminDigestBucket) error {
+	items := append([]models.DigestItem(nil), b.Items...)
+	// Escalations lead, then the newest reports.
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Type == "escalation" && items[j].Type != "escalation"
+	})
+
+	n, stored, err := insertNotification(ctx, models.Notification{
+		EventID:     "admin-digest:" + b.ID,
+		Audience:    models.AudienceAdmin,
+		Type:        adminDigestType,
+		Title:       "Ringkasan Laporan " + departmentLabel(b.Department),
+		Message:     adminDigestMessage(b),
+		Departments: []string{b.Department},
+		CreatedAt:   b.FlushAt,
+		Digest: &models.DigestSummary{
+			From:   b.WindowStart,
+			To:     b.FlushAt,
+			Total:  b.Total,
+			Counts: b.Counts,
+			Items:  items,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if stored {
+		pushLive(ctx, n)
+		log.Printf("[OK] Admin digest of %d notification(s) stored for department %s", b.Total, b.Department)
+	}
+	return enqueueDeliveries(ctx, n)
+}
+
+func adminDigestMessage(b models.AdminDigestBucket) string {
+	minutes := int(b.FlushAt.Sub(b.WindowStart).Minutes())
+	parts := []string{fmt.Sprintf("%d laporan baru", b.Counts["new_report"])}
+	if c := b.Counts["escalation"]; c > 0 {
+		parts = append(parts, fmt.Sprintf("%d eskalasi", c))
+	}
+	return fmt.Sprintf("%s dalam %d menit terakhir", strings.Join(parts, ", "), minutes)
+}
+
+func departmentLabel(dept string) string {
+	words := strings.Split(dept, "_")
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// adminDigestChannels are the channels an admin chose for any of the event
+// types counted in the digest.
+func adminDigestChannels(p models.Preferences, n models.Notification) []string {
+	var out []string
+	if n.Digest == nil {
+		return out
+	}
+	for t := range n.Digest.Counts {
+		for _, ch := range channelsFor(p, t) {
+			if !containsString(out, ch) {
+				out = append(out, ch)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// notificationChannels picks the user's channels for n.
+func notificationChannels(p models.Preferences, n models.Notification) []string {
+	if n.Type == adminDigestType {
+		return adminDigestChannels(p, n)
+	}
+	return channelsFor(p, n.Type)
+}
@@ -0,0 +1,603 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/pkg/telemetry"
+	"citizen-reporting-system/services/notification-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	announcementsCollection  = "announcements"
+	announcementType         = "announcement"
+	announcementLease        = 5 * time.Minute
+	announcementPollInterval = 15 * time.Second
+	announcementPageSize     = 500
+	announcementFeedLimit    = 200
+	announcementMaxValidity  = 90 * 24 * time.Hour
+	announcementMaxPoints    = 1000
+)
+
+var (
+	announcementSeverities = map[string]int{
+		models.SeverityInfo:     0,
+		models.SeverityWarning:  1,
+		models.SeverityCritical: 2,
+	}
+
+	// Region codes are hierarchical administrative codes such as "31.71.01".
+	regionCodeRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+)
+
+func ensureAnnouncementIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(announcementsCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "fanout_done", Value: 1}, {Key: "valid_from", Value: 1}}},
+		{Keys: bson.D{{Key: "valid_until", Value: 1}, {Key: "valid_from", Value: -1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+// regionWithin reports whether region lies in area, i.e. equals it or is
+// one of its sub-regions.
+func regionWithin(region, area string) bool {
+	return region == area || strings.HasPrefix(region, area+".")
+}
+
+// pointInPolygon is a ray-casting test for a point against a ring of
+// [lng, lat] points.
+func pointInPolygon(lng, lat float64, polygon [][2]float64) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		xi, yi := polygon[i][0], polygon[i][1]
+		xj, yj := polygon[j][0], polygon[j][1]
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func polygonBounds(polygon [][2]float64) (minLat, maxLat, minLng, maxLng float64) {
+	minLng, minLat = polygon[0][0], polygon[0][1]
+	maxLng, maxLat = minLng, minLat
+	for _, p := range polygon[1:] {
+		if p[0] < minLng {
+			minLng = p[0]
+		}
+		if p[0] > maxLng {
+			maxLng = p[0]
+		}
+		if p[1] < minLat {
+			minLat = p[1]
+		}
+		if p[1] > maxLat {
+			maxLat = p[1]
+		}
+	}
+	return
+}
+
+func validateAnnouncementArea(area *models.AnnouncementArea) error {
+	area.RegionCode = strings.TrimSpace(area.RegionCode)
+	switch {
+	case area.RegionCode != "" && len(area.Polygon) > 0:
+		return fmt.Errorf("area takes a region code or a polygon, not both")
+	case area.RegionCode != "":
+		if !regionCodeRegex.MatchString(area.RegionCode) {
+			return fmt.Errorf("invalid region code %q", area.RegionCode)
+		}
+	case len(area.Polygon) > 0:
+		if len(area.Polygon) < 3 || len(area.Polygon) > announcementMaxPoints {
+			return fmt.Errorf("polygon must have between 3 and %d points", announcementMaxPoints)
+		}
+		for _, p := range area.Polygon {
+			if p[0] < -180 || p[0] > 180 || p[1] < -90 || p[1] > 90 {
+				return fmt.Errorf("polygon point %v out of range; points are [lng, lat]", p)
+			}
+		}
+	default:
+		return fmt.Errorf("area needs a region code or a polygon")
+	}
+	return nil
+}
+
+// canManageAnnouncement lets super-admins and general admins manage every
+// announcement, and other admins those of their own department.
+func canManageAnnouncement(claims *middleware.UserClaims, a models.Announcement) bool {
+	if claims.Role == "super-admin" {
+		return true
+	}
+	dept := canonicalDepartment(claims.Department)
+	return dept == "" || dept == "general" || dept == a.Department
+}
+
+func startAnnouncementWorker() {
+	ticker := time.NewTicker(announcementPollInterval)
+	defer ticker.Stop()
+
+	log.Println("[INFO] Announcement fan-out worker started")
+
+	for range ticker.C {
+		for i := 0; i < 20; i++ {
+			if !fanoutNextAnnouncement() {
+				break
+			}
+		}
+	}
+}
+
+// fanoutNextAnnouncement claims an active announcement that has residents
+// left to notify and sends it to the next page of them. Inbox entries are
+// keyed on the announcement and user, so a page retried after a failure
+// reaches nobody twice.
+func fanoutNextAnnouncement() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), announcementLease)
+	defer cancel()
+
+	now := time.Now()
+	var a models.Announcement
+	err := db.Collection(announcementsCollection).FindOneAndUpdate(ctx,
+		bson.M{
+			"fanout_done":  false,
+			"cancelled_at": bson.M{"$exists": false},
+			"valid_from":   bson.M{"$lte": now},
+			"valid_until":  bson.M{"$gt": now},
+			"$or": []bson.M{
+				{"locked_until": bson.M{"$exists": false}},
+				{"locked_until": bson.M{"$lte": now}},
+			},
+		},
+		bson.M{"$set": bson.M{"locked_until": now.Add(announcementLease), "locked_by": instanceID}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "valid_from", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&a)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("[ERROR] Announcements: failed to claim announcement: %v", err)
+		}
+		return false
+	}
+
+	residents, err := fetchResidents(ctx, a.Area, a.FanoutCursor)
+	if err != nil {
+		// The lease expires and the page is retried.
+		log.Printf("[WARN] Announcement %s: failed to fetch residents: %v", a.ID.Hex(), err)
+		return false
+	}
+
+	sent := 0
+	for _, r := range residents {
+		if len(a.Area.Polygon) > 0 && (r.HomeLat == nil || r.HomeLng == nil || !pointInPolygon(*r.HomeLng, *r.HomeLat, a.Area.Polygon)) {
+			continue
+		}
+		if err := notifyResident(ctx, a, r.ID); err != nil {
+			log.Printf("[WARN] Announcement %s: failed to notify user %s: %v", a.ID.Hex(), r.ID, err)
+			return false
+		}
+		sent++
+	}
+
+	done := len(residents) < announcementPageSize
+	set := bson.M{"fanout_done": done}
+	if len(residents) > 0 {
+		set["fanout_cursor"] = residents[len(residents)-1].ID
+	}
+	_, err = db.Collection(announcementsCollection).UpdateOne(ctx,
+		bson.M{"_id": a.ID, "locked_by": instanceID},
+		bson.M{
+			"$set":   set,
+			"$inc":   bson.M{"recipients": sent},
+			"$unset": bson.M{"locked_until": "", "locked_by": ""},
+		},
+	)
+	if err != nil {
+		log.Printf("[ERROR] Announcement %s: failed to record progress: %v", a.ID.Hex(), err)
+		return false
+	}
+	if done {
+		log.Printf("[OK] Announcement %s sent to %d resident(s)", a.ID.Hex(), a.Recipients+sent)
+	}
+	return true
+}
+
+func notifyResident(ctx context.Context, a models.Announcement, userID string) error {
+	n, stored, err := insertNotification(ctx, models.Notification{
+		EventID:   "announcement:" + a.ID.Hex(),
+		Audience:  models.AudienceUser,
+		UserID:    userID,
+		Type:      announcementType,
+		Title:     a.Title,
+		Message:   a.Message,
+		Category:  a.Category,
+		Severity:  a.Severity,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if stored {
+		pushLive(ctx, n)
+	}
+	return enqueueDeliveries(ctx, n)
+}
+
+type resident struct {
+	ID         string   `json:"id"`
+	HomeRegion string   `json:"home_region"`
+	HomeLat    *float64 `json:"home_lat"`
+	HomeLng    *float64 `json:"home_lng"`
+}
+
+// fetchResidents asks auth-service for the next page of users whose home
+// area is in the announcement's area. Polygons are sent as their bounding
+// box and narrowed by the caller.
+func fetchResidents(ctx context.Context, area models.AnnouncementArea, after string) ([]resident, error) {
+	q := url.Values{}
+	if area.RegionCode != "" {
+		q.Set("region", area.RegionCode)
+	} else {
+		minLat, maxLat, minLng, maxLng := polygonBounds(area.Polygon)
+		q.Set("min_lat", strconv.FormatFloat(minLat, 'f', -1, 64))
+		q.Set("max_lat", strconv.FormatFloat(maxLat, 'f', -1, 64))
+		q.Set("min_lng", strconv.FormatFloat(minLng, 'f', -1, 64))
+		q.Set("max_lng", strconv.FormatFloat(maxLng, 'f', -1, 64))
+	}
+	if after != "" {
+		q.Set("after", after)
+	}
+	q.Set("limit", strconv.Itoa(announcementPageSize))
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authServiceURL()+"/internal/residents?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := middleware.SignInternalRequest(req, "auth-service"); err != nil {
+		return nil, err
+	}
+	resp, err := telemetry.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("auth-service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth-service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data []resident `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("auth-service: %w", err)
+	}
+	return body.Data, nil
+}
+
+// announcementsHandler is the admin API:
+//
+//	GET    /notifications/announcements        list (page, limit, status)
+//	POST   /notifications/announcements        create
+//	GET    /notifications/announcements/{id}
+//	DELETE /notifications/announcements/{id}   cancel
+func announcementsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims)
+	if !ok || claims.UserID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+	if !isAdminRole(claims.Role) {
+		response.Error(w, http.StatusForbidden, "Forbidden", "Only admins can manage announcements")
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/notifications/announcements"), "/")
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		listAnnouncements(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		createAnnouncement(w, r, claims)
+	case id != "" && !strings.Contains(id, "/") && r.Method == http.MethodGet:
+		getAnnouncement(w, r, id)
+	case id != "" && !strings.Contains(id, "/") && r.Method == http.MethodDelete:
+		cancelAnnouncement(w, r, claims, id)
+	default:
+		response.Error(w, http.StatusNotFound, "Not found", "")
+	}
+}
+
+func createAnnouncement(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims) {
+	var input struct {
+		Title      string                  `json:"title"`
+		Message    string                  `json:"message"`
+		Category   string                  `json:"category"`
+		Severity   string                  `json:"severity"`
+		Area       models.AnnouncementArea `json:"area"`
+		ValidFrom  *time.Time              `json:"valid_from"`
+		ValidUntil time.Time               `json:"valid_until"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	now := time.Now()
+	a := models.Announcement{
+		Title:      strings.TrimSpace(input.Title),
+		Message:    strings.TrimSpace(input.Message),
+		Category:   strings.TrimSpace(input.Category),
+		Severity:   strings.ToLower(strings.TrimSpace(input.Severity)),
+		Area:       input.Area,
+		ValidFrom:  now,
+		ValidUntil: input.ValidUntil,
+		Department: canonicalDepartment(claims.Department),
+		CreatedBy:  claims.UserID,
+		CreatedAt:  now,
+	}
+	if input.ValidFrom != nil && input.ValidFrom.After(now) {
+		a.ValidFrom = *input.ValidFrom
+	}
+	if a.Severity == "" {
+		a.Severity = models.SeverityInfo
+	}
+
+	switch {
+	case a.Title == "" || len(a.Title) > 200:
+		response.Error(w, http.StatusBadRequest, "Title is required and at most 200 characters", "")
+		return
+	case a.Message == "" || len(a.Message) > 2000:
+		response.Error(w, http.StatusBadRequest, "Message is required and at most 2000 characters", "")
+		return
+	case a.Category == "" || len(a.Category) > 100:
+		response.Error(w, http.StatusBadRequest, "Category is required and at most 100 characters", "")
+		return
+	}
+	if _, ok := announcementSeverities[a.Severity]; !ok {
+		response.Error(w, http.StatusBadRequest, "Invalid severity", "Use info, warning or critical")
+		return
+	}
+	if err := validateAnnouncementArea(&a.Area); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid area", err.Error())
+		return
+	}
+	if !a.ValidUntil.After(a.ValidFrom) || a.ValidUntil.Sub(a.ValidFrom) > announcementMaxValidity {
+		response.Error(w, http.StatusBadRequest, "Invalid validity window", "valid_until must be after valid_from and within 90 days")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	res, err := db.Collection(announcementsCollection).InsertOne(ctx, a)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to create announcement", err.Error())
+		return
+	}
+	a.ID = res.InsertedID.(primitive.ObjectID)
+
+	log.Printf("[OK] Announcement %s created by %s (%s, %s)", a.ID.Hex(), claims.UserID, a.Severity, a.Category)
+	response.Success(w, http.StatusCreated, "Announcement created", a.WithStatus(now))
+}
+
+func listAnnouncements(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > inboxMaxPageSize {
+		limit = inboxMaxPageSize
+	}
+
+	now := time.Now()
+	filter := bson.M{}
+	switch strings.ToUpper(q.Get("status")) {
+	case "":
+	case models.AnnouncementActive:
+		filter = activeAnnouncementFilter(now)
+	case models.AnnouncementScheduled:
+		filter = bson.M{"cancelled_at": bson.M{"$exists": false}, "valid_from": bson.M{"$gt": now}}
+	case models.AnnouncementExpired:
+		filter = bson.M{"cancelled_at": bson.M{"$exists": false}, "valid_until": bson.M{"$lte": now}}
+	case models.AnnouncementCancelled:
+		filter = bson.M{"cancelled_at": bson.M{"$exists": true}}
+	default:
+		response.Error(w, http.StatusBadRequest, "Invalid status", "")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	total, err := db.Collection(announcementsCollection).CountDocuments(ctx, filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to count announcements", err.Error())
+		return
+	}
+	cursor, err := db.Collection(announcementsCollection).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch announcements", err.Error())
+		return
+	}
+	announcements := make([]models.Announcement, 0)
+	if err := cursor.All(ctx, &announcements); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to decode announcements", err.Error())
+		return
+	}
+	for i := range announcements {
+		announcements[i] = announcements[i].WithStatus(now)
+	}
+
+	response.Success(w, http.StatusOK, "Announcements fetched successfully", map[string]interface{}{
+		"announcements": announcements,
+		"page":          page,
+		"limit":         limit,
+		"total":         total,
+	})
+}
+
+func findAnnouncement(ctx context.Context, w http.ResponseWriter, id string) (models.Announcement, bool) {
+	var a models.Announcement
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid announcement ID", err.Error())
+		return a, false
+	}
+	if err := db.Collection(announcementsCollection).FindOne(ctx, bson.M{"_id": objID}).Decode(&a); err != nil {
+		if err == mongo.ErrNoDocuments {
+			response.Error(w, http.StatusNotFound, "Announcement not found", "")
+		} else {
+			response.Error(w, http.StatusInternalServerError, "Failed to fetch announcement", err.Error())
+		}
+		return a, false
+	}
+	return a, true
+}
+
+func getAnnouncement(w http.ResponseWriter, r *http.Request, id string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	a, ok := findAnnouncement(ctx, w, id)
+	if !ok {
+		return
+	}
+	response.Success(w, http.StatusOK, "Announcement fetched successfully", a.WithStatus(time.Now()))
+}
+
+// cancelAnnouncement withdraws an announcement from the feed and stops its
+// fan-out. Residents already notified keep it in their inbox.
+func cancelAnnouncement(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims, id string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	a, ok := findAnnouncement(ctx, w, id)
+	if !ok {
+		return
+	}
+	if !canManageAnnouncement(claims, a) {
+		response.Error(w, http.StatusForbidden, "Forbidden", "Announcement belongs to another department")
+		return
+	}
+	if a.CancelledAt == nil {
+		now := time.Now()
+		if _, err := db.Collection(announcementsCollection).UpdateOne(ctx,
+			bson.M{"_id": a.ID, "cancelled_at": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"cancelled_at": now}},
+		); err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to cancel announcement", err.Error())
+			return
+		}
+		a.CancelledAt = &now
+		log.Printf("[INFO] Announcement %s cancelled by %s", a.ID.Hex(), claims.UserID)
+	}
+	response.Success(w, http.StatusOK, "Announcement cancelled", a.WithStatus(time.Now()))
+}
+
+func activeAnnouncementFilter(now time.Time) bson.M {
+	return bson.M{
+		"cancelled_at": bson.M{"$exists": false},
+		"valid_from":   bson.M{"$lte": now},
+		"valid_until":  bson.M{"$gt": now},
+	}
+}
+
+// activeAnnouncementsHandler serves the public feed,
+// GET /notifications/announcements/active, most severe first. region or
+// lat and lng narrow it to announcements covering that place; category
+// filters by category.
+func activeAnnouncementsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+	q := r.URL.Query()
+
+	region := strings.TrimSpace(q.Get("region"))
+	if region != "" && !regionCodeRegex.MatchString(region) {
+		response.Error(w, http.StatusBadRequest, "Invalid region code", "")
+		return
+	}
+	var point *[2]float64
+	if q.Get("lat") != "" || q.Get("lng") != "" {
+		lat, errLat := strconv.ParseFloat(q.Get("lat"), 64)
+		lng, errLng := strconv.ParseFloat(q.Get("lng"), 64)
+		if errLat != nil || errLng != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid coordinates", "")
+			return
+		}
+		point = &[2]float64{lng, lat}
+	}
+
+	now := time.Now()
+	filter := activeAnnouncementFilter(now)
+	if category := strings.TrimSpace(q.Get("category")); category != "" {
+		filter["category"] = category
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection(announcementsCollection).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "valid_from", Value: -1}}).
+		SetLimit(announcementFeedLimit))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch announcements", err.Error())
+		return
+	}
+	var found []models.Announcement
+	if err := cursor.All(ctx, &found); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to decode announcements", err.Error())
+		return
+	}
+
+	announcements := make([]models.Announcement, 0, len(found))
+	for _, a := range found {
+		if region != "" || point != nil {
+			inRegion := region != "" && a.Area.RegionCode != "" && regionWithin(region, a.Area.RegionCode)
+			inPolygon := point != nil && len(a.Area.Polygon) > 0 && pointInPolygon(point[0], point[1], a.Area.Polygon)
+			if !inRegion && !inPolygon {
+				continue
+			}
+		}
+		a.CreatedBy = ""
+		announcements = append(announcements, a.WithStatus(now))
+	}
+	sort.SliceStable(announcements, func(i, j int) bool {
+		return announcementSeverities[announcements[i].Severity] > announcementSeverities[announcements[j].Severity]
+	})
+
+	response.Success(w, http.StatusOK, "Active announcements fetched successfully", map[string]interface{}{
+		"announcements": announcements,
+	})
+}
@@ -0,0 +1,88 @@
+package main
+
+import "testing"
+
+func TestRegionWithin(t *testing.T) {
+	tests := []struct {
+		region, area string
+		want         bool
+	}{
+		{"31.71", "31.71", true},
+		{"31.71.01", "31.71", true},
+		{"31.71.01.1001", "31", true},
+		{"31.710", "31.71", false},
+		{"31", "31.71", false},
+		{"32.71.01", "31.71", false},
+		{"", "31.71", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region+" in "+tt.area, func(t *testing.T) {
+			if got := regionWithin(tt.region, tt.area); got != tt.want {
+				t.Errorf("regionWithin(%q, %q) = %v, want %v", tt.region, tt.area, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPointInPolygon(t *testing.T) {
+	square := [][2]float64{{106.80, -6.20}, {106.90, -6.20}, {106.90, -6.10}, {106.80, -6.10}}
+	closed := append(append([][2]float64{}, square...), square[0])
+	// A U shape whose notch between lng 106.83 and 106.87 is open to the north.
+	concave := [][2]float64{
+		{106.80, -6.20}, {106.90, -6.20}, {106.90, -6.10}, {106.87, -6.10},
+		{106.87, -6.17}, {106.83, -6.17}, {106.83, -6.10}, {106.80, -6.10},
+	}
+
+	tests := []struct {
+		name     string
+		lng, lat float64
+		polygon  [][2]float64
+		want     bool
+	}{
+		{"centre", 106.85, -6.15, square, true},
+		{"west of the square", 106.79, -6.15, square, false},
+		{"north of the square", 106.85, -6.09, square, false},
+		{"level with a vertex but outside", 106.95, -6.20, square, false},
+		{"repeated closing point", 106.85, -6.15, closed, true},
+		{"concave arm", 106.81, -6.12, concave, true},
+		{"concave notch", 106.85, -6.12, concave, false},
+		{"concave base below the notch", 106.85, -6.19, concave, true},
+		{"too few points", 106.85, -6.15, square[:2], false},
+		{"empty polygon", 106.85, -6.15, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pointInPolygon(tt.lng, tt.lat, tt.polygon); got != tt.want {
+				t.Errorf("pointInPolygon(%v, %v) = %v, want %v", tt.lng, tt.lat, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPointInPolygonSharedEdge checks that a point on the border between
+// two adjacent areas is claimed by exactly one of them, so an announcement
+// split across neighbouring polygons reaches it once.
+func TestPointInPolygonSharedEdge(t *testing.T) {
+	west := [][2]float64{{106.80, -6.20}, {106.85, -6.20}, {106.85, -6.10}, {106.80, -6.10}}
+	east := [][2]float64{{106.85, -6.20}, {106.90, -6.20}, {106.90, -6.10}, {106.85, -6.10}}
+	south := [][2]float64{{106.80, -6.30}, {106.90, -6.30}, {106.90, -6.20}, {106.80, -6.20}}
+
+	tests := []struct {
+		name     string
+		lng, lat float64
+		a, b     [][2]float64
+	}{
+		{"on the vertical border", 106.85, -6.15, west, east},
+		{"on the horizontal border", 106.82, -6.20, west, south},
+		{"on the shared corner", 106.85, -6.20, west, east},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inA := pointInPolygon(tt.lng, tt.lat, tt.a)
+			inB := pointInPolygon(tt.lng, tt.lat, tt.b)
+			if inA == inB {
+				t.Errorf("point in both/neither polygon: a=%v b=%v", inA, inB)
+			}
+		})
+	}
+}
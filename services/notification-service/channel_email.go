@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type smtpChannel struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+func newSMTPChannel() *smtpChannel {
+	c := &smtpChannel{
+		host:     os.Getenv("SMTP_HOST"),
+		port:     os.Getenv("SMTP_PORT"),
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     os.Getenv("SMTP_FROM"),
+	}
+	if c.port == "" {
+		c.port = "587"
+	}
+	return c
+}
+
+func (c *smtpChannel) Name() string { return "email" }
+
+func (c *smtpChannel) Send(ctx context.Context, to Contact, msg Message) (string, error) {
+	if to.Email == "" {
+		return "", errNoAddress
+	}
+	if c.host == "" || c.from == "" {
+		return "", permanent(fmt.Errorf("smtp not configured"))
+	}
+	if _, err := mail.ParseAddress(to.Email); err != nil {
+		return "", permanent(fmt.Errorf("invalid email address: %w", err))
+	}
+
+	messageID := fmt.Sprintf("<%s@%s>", uuid.NewString(), c.host)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", c.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to.Email)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "X-Notification-Delivery: %s\r\n", msg.DeliveryID)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if c.username != "" {
+		auth = smtp.PlainAuth("", c.username, c.password, c.host)
+	}
+
+	// net/smtp has no context support, so run it aside and honour the timeout.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(c.host, c.port), auth, c.from, []string{to.Email}, b.Bytes())
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return "", fmt.Errorf("smtp: %w", err)
+		}
+	case <-ctx.Done():
+		return "", fmt.Errorf("smtp: %w", ctx.Err())
+	}
+	return messageID, nil
+}
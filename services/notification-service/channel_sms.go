@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"citizen-reporting-system/pkg/telemetry"
+)
+
+// smsGatewayChannel posts messages to an HTTP SMS gateway:
+//
+//	POST $SMS_GATEWAY_URL
+//	Authorization: Bearer $SMS_GATEWAY_TOKEN
+//	{"to":"+62...","message":"...","sender":"...","reference":"<delivery id>"}
+//
+// and expects a 2xx reply, optionally with {"id":"..."} as the reference.
+type smsGatewayChannel struct {
+	url    string
+	token  string
+	sender string
+}
+
+func newSMSGatewayChannel() *smsGatewayChannel {
+	return &smsGatewayChannel{
+		url:    os.Getenv("SMS_GATEWAY_URL"),
+		token:  os.Getenv("SMS_GATEWAY_TOKEN"),
+		sender: os.Getenv("SMS_SENDER"),
+	}
+}
+
+func (c *smsGatewayChannel) Name() string { return "sms" }
+
+// normalizePhone turns local Indonesian numbers (08...) into E.164.
+func normalizePhone(phone string) string {
+	p := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	switch {
+	case strings.HasPrefix(p, "+"):
+		return p
+	case strings.HasPrefix(p, "62"):
+		return "+" + p
+	case strings.HasPrefix(p, "0"):
+		return "+62" + p[1:]
+	}
+	return p
+}
+
+func (c *smsGatewayChannel) Send(ctx context.Context, to Contact, msg Message) (string, error) {
+	if to.Phone == "" {
+		return "", errNoAddress
+	}
+	if c.url == "" {
+		return "", permanent(fmt.Errorf("sms gateway not configured"))
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"to":        normalizePhone(to.Phone),
+		"message":   msg.Body,
+		"sender":    c.sender,
+		"reference": msg.DeliveryID,
+	})
+	if err != nil {
+		return "", permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return "", permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := telemetry.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sms gateway: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return "", fmt.Errorf("sms gateway returned %d", resp.StatusCode)
+	default:
+		return "", permanent(fmt.Errorf("sms gateway returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody))))
+	}
+
+	var out struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(respBody, &out)
+	if out.ID == "" {
+		out.ID = msg.DeliveryID
+	}
+	return out.ID, nil
+}
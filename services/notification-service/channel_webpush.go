@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/pkg/security"
+	"citizen-reporting-system/pkg/telemetry"
+	"citizen-reporting-system/services/notification-service/models"
+
+	webpush "github.com/SherClockHolmes/webpush-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	pushSubscriptionCollection = "push_subscriptions"
+	pushTTL                    = 24 * 60 * 60
+)
+
+// pushClient only connects to public addresses. Endpoints come from the
+// browser, so a user could otherwise name a host inside the network and
+// have the service post to it on every notification.
+var pushClient = &http.Client{
+	Transport: telemetry.NewTransport(&http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: security.PublicDialControl,
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConns:        50,
+		IdleConnTimeout:     90 * time.Second,
+	}),
+	Timeout: 15 * time.Second,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// webPushChannel sends RFC 8030 push messages, encrypted per RFC 8291 and
+// signed with the service's VAPID key (RFC 8292), to every browser the user
+// subscribed.
+type webPushChannel struct {
+	publicKey  string
+	privateKey string
+	subject    string
+}
+
+func vapidPublicKey() string {
+	return strings.TrimSpace(os.Getenv("VAPID_PUBLIC_KEY"))
+}
+
+func newWebPushChannel() (*webPushChannel, error) {
+	c := &webPushChannel{
+		publicKey:  vapidPublicKey(),
+		privateKey: strings.TrimSpace(os.Getenv("VAPID_PRIVATE_KEY")),
+		subject:    os.Getenv("VAPID_SUBJECT"),
+	}
+	if c.publicKey == "" || c.privateKey == "" {
+		return nil, fmt.Errorf("VAPID_PUBLIC_KEY and VAPID_PRIVATE_KEY are required")
+	}
+	if c.subject == "" {
+		c.subject = "mailto:admin@localhost"
+	}
+	return c, nil
+}
+
+func (c *webPushChannel) Name() string { return "push" }
+
+func ensurePushIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(pushSubscriptionCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "endpoint", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	return err
+}
+
+func pushSubscriptions(ctx context.Context, userID string) ([]models.PushSubscription, error) {
+	cursor, err := db.Collection(pushSubscriptionCollection).Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	var subs []models.PushSubscription
+	err = cursor.All(ctx, &subs)
+	return subs, err
+}
+
+// Send pushes to each of the user's subscriptions. It succeeds if any
+// browser accepted the message; subscriptions the push service reports as
+// gone are deleted.
+func (c *webPushChannel) Send(ctx context.Context, to Contact, msg Message) (string, error) {
+	subs, err := pushSubscriptions(ctx, to.UserID)
+	if err != nil {
+		return "", err
+	}
+	if len(subs) == 0 {
+		return "", errNoAddress
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"title":       msg.Subject,
+		"body":        msg.Body,
+		"url":         msg.URL,
+		"delivery_id": msg.DeliveryID,
+	})
+	if err != nil {
+		return "", permanent(err)
+	}
+
+	sent := 0
+	var lastErr error
+	for _, sub := range subs {
+		// Subscriptions saved before endpoints were checked go through the
+		// same rules; one pointing inside the network is dropped.
+		if _, err := security.ValidatePublicURL(sub.Endpoint); err != nil {
+			_, _ = db.Collection(pushSubscriptionCollection).DeleteOne(ctx, bson.M{"_id": sub.ID})
+			log.Printf("[WARN] Removed push subscription %s for user %s: %v", sub.ID.Hex(), to.UserID, err)
+			continue
+		}
+		resp, err := webpush.SendNotificationWithContext(ctx, payload, &webpush.Subscription{
+			Endpoint: sub.Endpoint,
+			Keys:     webpush.Keys{Auth: sub.Auth, P256dh: sub.P256dh},
+		}, &webpush.Options{
+			HTTPClient:      pushClient,
+			Subscriber:      c.subject,
+			VAPIDPublicKey:  c.publicKey,
+			VAPIDPrivateKey: c.privateKey,
+			TTL:             pushTTL,
+			Urgency:         webpush.UrgencyNormal,
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+			_, _ = db.Collection(pushSubscriptionCollection).DeleteOne(ctx, bson.M{"_id": sub.ID})
+			log.Printf("[INFO] Removed expired push subscription %s for user %s", sub.ID.Hex(), to.UserID)
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			sent++
+			_, _ = db.Collection(pushSubscriptionCollection).UpdateOne(ctx, bson.M{"_id": sub.ID},
+				bson.M{"$set": bson.M{"last_used": time.Now()}})
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("push service returned %d", resp.StatusCode)
+		default:
+			lastErr = permanent(fmt.Errorf("push service returned %d", resp.StatusCode))
+		}
+	}
+
+	switch {
+	case sent > 0:
+		return fmt.Sprintf("%d/%d subscriptions", sent, len(subs)), nil
+	case lastErr == nil:
+		// Every subscription had expired.
+		return "", errNoAddress
+	}
+	return "", lastErr
+}
+
+// pushHandler serves the browser side of Web Push:
+//
+//	GET    /notifications/push/vapid-public-key
+//	POST   /notifications/push/subscriptions     PushSubscription JSON
+//	DELETE /notifications/push/subscriptions     {"endpoint": "..."}
+func pushHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims)
+	if !ok || claims.UserID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	switch rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/notifications/push"), "/"); {
+	case rest == "vapid-public-key" && r.Method == http.MethodGet:
+		key := vapidPublicKey()
+		if key == "" {
+			response.Error(w, http.StatusServiceUnavailable, "Web Push is not configured", "")
+			return
+		}
+		response.Success(w, http.StatusOK, "VAPID public key fetched", map[string]string{"public_key": key})
+	case rest == "subscriptions" && r.Method == http.MethodPost:
+		subscribePush(w, r, claims)
+	case rest == "subscriptions" && r.Method == http.MethodDelete:
+		unsubscribePush(w, r, claims)
+	default:
+		response.Error(w, http.StatusNotFound, "Not found", "")
+	}
+}
+
+func subscribePush(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims) {
+	var input webpush.Subscription
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if _, err := security.ValidatePublicURL(input.Endpoint); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid endpoint", err.Error())
+		return
+	}
+	if input.Keys.P256dh == "" || input.Keys.Auth == "" {
+		response.Error(w, http.StatusBadRequest, "keys.p256dh and keys.auth are required", "")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// The endpoint identifies the browser, so re-subscribing (or another
+	// user signing in on it) takes the subscription over.
+	var sub models.PushSubscription
+	err := db.Collection(pushSubscriptionCollection).FindOneAndUpdate(ctx,
+		bson.M{"endpoint": input.Endpoint},
+		bson.M{
+			"$set": bson.M{
+				"user_id":    claims.UserID,
+				"p256dh":     input.Keys.P256dh,
+				"auth":       input.Keys.Auth,
+				"user_agent": r.UserAgent(),
+			},
+			"$setOnInsert": bson.M{"created_at": time.Now()},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&sub)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to save subscription", err.Error())
+		return
+	}
+
+	log.Printf("[INFO] Push subscription %s saved for user %s", sub.ID.Hex(), claims.UserID)
+	response.Success(w, http.StatusCreated, "Push subscription saved", sub)
+}
+
+func unsubscribePush(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims) {
+	var input struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Endpoint == "" {
+		response.Error(w, http.StatusBadRequest, "endpoint is required", "")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	res, err := db.Collection(pushSubscriptionCollection).DeleteOne(ctx,
+		bson.M{"endpoint": input.Endpoint, "user_id": claims.UserID})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to delete subscription", err.Error())
+		return
+	}
+	if res.DeletedCount == 0 {
+		response.Error(w, http.StatusNotFound, "Subscription not found", "")
+		return
+	}
+	response.Success(w, http.StatusOK, "Push subscription deleted", nil)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"citizen-reporting-system/services/notification-service/models"
+)
+
+// Contact is what auth-service knows about a recipient.
+type Contact struct {
+	UserID   string `json:"-"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Language string `json:"language"`
+}
+
+// Message is a rendered delivery, ready for a channel.
+type Message struct {
+	DeliveryID string
+	Subject    string
+	Body       string
+	URL        string
+}
+
+// Channel sends a message to one recipient and returns the provider's
+// reference for it.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, to Contact, msg Message) (string, error)
+}
+
+// errNoAddress means the recipient has nothing to send to on the channel;
+// the delivery is marked skipped rather than failed.
+var errNoAddress = errors.New("recipient has no address for this channel")
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent marks an error that retrying cannot fix, e.g. a rejected number.
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+var channels = map[string]Channel{}
+
+// setupChannels picks a driver per channel. Every channel defaults to its
+// fake driver, which only logs, so a development stack never sends real
+// messages.
+func setupChannels() {
+	switch driver := strings.ToLower(os.Getenv("NOTIFY_EMAIL_DRIVER")); driver {
+	case "smtp":
+		channels[models.ChannelEmail] = newSMTPChannel()
+	case "", "fake":
+		channels[models.ChannelEmail] = fakeChannel{name: models.ChannelEmail}
+	default:
+		log.Fatalf("[ERROR] Unknown NOTIFY_EMAIL_DRIVER %q", driver)
+	}
+
+	switch driver := strings.ToLower(os.Getenv("NOTIFY_SMS_DRIVER")); driver {
+	case "http":
+		channels[models.ChannelSMS] = newSMSGatewayChannel()
+	case "", "fake":
+		channels[models.ChannelSMS] = fakeChannel{name: models.ChannelSMS}
+	default:
+		log.Fatalf("[ERROR] Unknown NOTIFY_SMS_DRIVER %q", driver)
+	}
+
+	switch driver := strings.ToLower(os.Getenv("NOTIFY_PUSH_DRIVER")); driver {
+	case "vapid":
+		ch, err := newWebPushChannel()
+		if err != nil {
+			log.Fatalf("[ERROR] NOTIFY_PUSH_DRIVER=vapid but Web Push is not configured: %v", err)
+		}
+		channels[models.ChannelPush] = ch
+	case "", "fake":
+		channels[models.ChannelPush] = fakeChannel{name: models.ChannelPush}
+	default:
+		log.Fatalf("[ERROR] Unknown NOTIFY_PUSH_DRIVER %q", driver)
+	}
+
+	for _, name := range enabledChannels() {
+		log.Printf("[INFO] Channel %s using %T", name, channels[name])
+	}
+}
+
+// enabledChannels lists the channels deliveries are created for, from
+// NOTIFY_CHANNELS (default all).
+func enabledChannels() []string {
+	v := os.Getenv("NOTIFY_CHANNELS")
+	if strings.TrimSpace(v) == "" {
+		return []string{models.ChannelEmail, models.ChannelSMS, models.ChannelPush}
+	}
+	var out []string
+	for _, name := range strings.Split(v, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := channels[name]; ok && !containsString(out, name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// fakeChannel logs messages instead of sending them.
+type fakeChannel struct{ name string }
+
+func (c fakeChannel) Name() string { return c.name }
+
+func (c fakeChannel) Send(ctx context.Context, to Contact, msg Message) (string, error) {
+	address := to.UserID
+	switch c.name {
+	case models.ChannelEmail:
+		address = to.Email
+	case models.ChannelSMS:
+		address = to.Phone
+	}
+	if address == "" {
+		return "", errNoAddress
+	}
+	log.Printf("[INFO] [fake %s] to=%s subject=%q body=%q", c.name, address, msg.Subject, msg.Body)
+	return fmt.Sprintf("fake-%s-%s", c.name, msg.DeliveryID), nil
+}
@@ -0,0 +1,617 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/pkg/telemetry"
+	"citizen-reporting-system/services/notification-service/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	deliveryCollection   = "deliveries"
+	deliveryLockDuration = time.Minute
+	contactCacheTTL      = 5 * time.Minute
+)
+
+var (
+	deliveryMaxAttempts  = envInt("NOTIFY_MAX_ATTEMPTS", 6)
+	deliveryRetryBase    = envDuration("NOTIFY_RETRY_BASE", 30*time.Second)
+	deliveryRetryMax     = envDuration("NOTIFY_RETRY_MAX", time.Hour)
+	deliveryPollInterval = envDuration("NOTIFY_POLL_INTERVAL", 2*time.Second)
+	deliverySendTimeout  = envDuration("NOTIFY_SEND_TIMEOUT", 15*time.Second)
+
+	channelDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "notification_channel_deliveries_total",
+		Help: "Email, SMS and push deliveries by final status",
+	}, []string{"channel", "status"})
+
+	contacts       = &contactCache{entries: map[string]cachedContact{}}
+	adminDirectory = &adminCache{}
+)
+
+func registerDeliveryMetrics() {
+	prometheus.MustRegister(channelDeliveries)
+}
+
+func authServiceURL() string {
+	if v := strings.TrimRight(os.Getenv("AUTH_SERVICE_URL"), "/"); v != "" {
+		return v
+	}
+	return "http://localhost:8081"
+}
+
+func ensureDeliveryIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Admin notifications have several recipients, so the key now includes
+	// the user; drop the older per-notification key if present.
+	_, _ = db.Collection(deliveryCollection).Indexes().DropOne(ctx, "notification_id_1_channel_1")
+
+	_, err := db.Collection(deliveryCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "notification_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "channel", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(inboxRetention().Seconds())),
+		},
+	})
+	return err
+}
+
+// enqueueDeliveries creates the pending deliveries for a stored
+// notification: one per recipient and channel they chose for its event.
+// Recipients with a daily digest get the event in the digest instead, and
+// admin events batched per department go out with the admin digest. The
+// deliveries are keyed on (notification, user, channel), so a redelivered
+// event adds nothing twice.
+func enqueueDeliveries(ctx context.Context, n models.Notification) error {
+	if n.ID.IsZero() || batchedForAdminDigest(n) {
+		return nil
+	}
+	recipients, err := deliveryRecipients(ctx, n)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+	prefs, err := loadPreferencesMany(ctx, recipients)
+	if err != nil {
+		return err
+	}
+
+	enabled := enabledChannels()
+	targets := map[string][]string{}
+	for _, userID := range recipients {
+		p := prefs[userID]
+		if p.Digest.Enabled {
+			continue
+		}
+		for _, channel := range notificationChannels(p, n) {
+			if containsString(enabled, channel) {
+				targets[userID] = append(targets[userID], channel)
+			}
+		}
+	}
+	return queueDeliveries(ctx, n, targets)
+}
+
+// deliveryRecipients lists the users a notification is addressed to: the
+// citizen for user notifications, the admins covering the category for
+// admin ones.
+func deliveryRecipients(ctx context.Context, n models.Notification) ([]string, error) {
+	if n.Audience == models.AudienceUser {
+		if n.UserID == "" {
+			return nil, nil
+		}
+		return []string{n.UserID}, nil
+	}
+
+	admins, err := adminDirectory.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, a := range admins {
+		if containsString(n.HiddenFor, a.ID) {
+			continue
+		}
+		if visibleTo(n, &Client{UserID: a.ID, AccessRole: a.Role, Department: a.Department}) {
+			out = append(out, a.ID)
+		}
+	}
+	return out, nil
+}
+
+// queueDeliveries writes pending deliveries of n for each user's channels.
+func queueDeliveries(ctx context.Context, n models.Notification, targets map[string][]string) error {
+	traceContext := map[string]string{}
+	telemetry.Inject(ctx, traceContext)
+
+	now := time.Now()
+	var writes []mongo.WriteModel
+	for userID, userChannels := range targets {
+		for _, channel := range userChannels {
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"notification_id": n.ID, "user_id": userID, "channel": channel}).
+				SetUpdate(bson.M{"$setOnInsert": models.Delivery{
+					NotificationID: n.ID,
+					UserID:         userID,
+					Audience:       n.Audience,
+					Category:       n.Category,
+					Departments:    n.Departments,
+					Channel:        channel,
+					Status:         models.DeliveryPending,
+					NextAttemptAt:  now,
+					CreatedAt:      now,
+					TraceContext:   traceContext,
+				}}).
+				SetUpsert(true))
+		}
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	_, err := db.Collection(deliveryCollection).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+type adminAccount struct {
+	ID         string `json:"id"`
+	Role       string `json:"role"`
+	Department string `json:"department"`
+}
+
+type adminCache struct {
+	mu      sync.Mutex
+	admins  []adminAccount
+	expires time.Time
+}
+
+// list returns the admin accounts from auth-service, cached like contacts.
+func (c *adminCache) list(ctx context.Context) ([]adminAccount, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().Before(c.expires) {
+		return c.admins, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authServiceURL()+"/internal/users?role=admin", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := middleware.SignInternalRequest(req, "auth-service"); err != nil {
+		return nil, err
+	}
+	resp, err := telemetry.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("auth-service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth-service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data []adminAccount `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("auth-service: %w", err)
+	}
+	c.admins = body.Data
+	c.expires = time.Now().Add(contactCacheTTL)
+	return c.admins, nil
+}
+
+type cachedContact struct {
+	contact Contact
+	expires time.Time
+}
+
+type contactCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedContact
+}
+
+// lookup fetches the user's contact details from auth-service, caching them
+// briefly so a burst of deliveries to one user makes a single request.
+func (c *contactCache) lookup(ctx context.Context, userID string) (Contact, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[userID]; ok && time.Now().Before(e.expires) {
+		c.mu.Unlock()
+		return e.contact, nil
+	}
+	c.mu.Unlock()
+
+	contact, err := fetchContact(ctx, userID)
+	if err != nil {
+		return Contact{}, err
+	}
+
+	c.mu.Lock()
+	c.entries[userID] = cachedContact{contact: contact, expires: time.Now().Add(contactCacheTTL)}
+	if len(c.entries) > 10000 {
+		now := time.Now()
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.mu.Unlock()
+	return contact, nil
+}
+
+func (c *contactCache) forget(userID string) {
+	c.mu.Lock()
+	delete(c.entries, userID)
+	c.mu.Unlock()
+}
+
+func fetchContact(ctx context.Context, userID string) (Contact, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authServiceURL()+"/internal/users/"+userID+"/contact", nil)
+	if err != nil {
+		return Contact{}, permanent(err)
+	}
+	if err := middleware.SignInternalRequest(req, "auth-service"); err != nil {
+		return Contact{}, err
+	}
+
+	resp, err := telemetry.HTTPClient.Do(req)
+	if err != nil {
+		return Contact{}, fmt.Errorf("auth-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return Contact{}, permanent(fmt.Errorf("user %s not found", userID))
+	case resp.StatusCode != http.StatusOK:
+		return Contact{}, fmt.Errorf("auth-service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data Contact `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Contact{}, fmt.Errorf("auth-service: %w", err)
+	}
+	body.Data.UserID = userID
+	return body.Data, nil
+}
+
+func deliveryBackoff(attempts int) time.Duration {
+	d := deliveryRetryBase
+	for i := 1; i < attempts && d < deliveryRetryMax; i++ {
+		d *= 2
+	}
+	if d > deliveryRetryMax {
+		d = deliveryRetryMax
+	}
+	return d
+}
+
+func startDeliveryWorker() {
+	ticker := time.NewTicker(deliveryPollInterval)
+	defer ticker.Stop()
+
+	log.Println("[INFO] Channel delivery worker started")
+
+	for range ticker.C {
+		for i := 0; i < 50; i++ {
+			if !deliverNext() {
+				break
+			}
+		}
+	}
+}
+
+// deliverNext claims one due delivery with a lease, so several replicas can
+// run the worker, and sends it.
+func deliverNext() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), deliverySendTimeout+15*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var d models.Delivery
+	err := db.Collection(deliveryCollection).FindOneAndUpdate(ctx,
+		bson.M{
+			"status":          models.DeliveryPending,
+			"next_attempt_at": bson.M{"$lte": now},
+			"$or": []bson.M{
+				{"locked_until": bson.M{"$exists": false}},
+				{"locked_until": bson.M{"$lt": now}},
+			},
+		},
+		bson.M{"$set": bson.M{"locked_until": now.Add(deliveryLockDuration), "locked_by": instanceID}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&d)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("[ERROR] Deliveries: failed to claim delivery: %v", err)
+		}
+		return false
+	}
+
+	if containsString(quietChannels, d.Channel) {
+		if p, err := loadPreferences(ctx, d.UserID); err == nil {
+			if until := quietUntil(p, now); !until.IsZero() {
+				_, _ = db.Collection(deliveryCollection).UpdateOne(ctx, bson.M{"_id": d.ID}, bson.M{
+					"$set":   bson.M{"next_attempt_at": until},
+					"$unset": bson.M{"locked_until": "", "locked_by": ""},
+				})
+				return true
+			}
+		}
+	}
+
+	ctx, span := telemetry.Tracer().Start(telemetry.Extract(ctx, d.TraceContext), "notify."+d.Channel,
+		trace.WithAttributes(
+			attribute.String("notification.delivery_id", d.ID.Hex()),
+			attribute.String("notification.channel", d.Channel),
+			attribute.Int("notification.attempt", d.Attempts+1),
+		),
+	)
+	defer span.End()
+
+	update, err := sendDelivery(ctx, &d)
+	attempt := models.DeliveryAttempt{At: now, Reference: d.Reference, DurationMs: time.Since(now).Milliseconds()}
+	if err != nil {
+		attempt.Error = err.Error()
+		span.SetStatus(codes.Error, attempt.Error)
+	}
+
+	set := bson.M{"attempts": d.Attempts + 1}
+	for k, v := range update {
+		set[k] = v
+	}
+	switch {
+	case err == nil:
+		set["status"] = models.DeliverySent
+		set["sent_at"] = time.Now()
+		set["reference"] = d.Reference
+	case errors.Is(err, errNoAddress):
+		set["status"] = models.DeliverySkipped
+		set["last_error"] = err.Error()
+	case isPermanent(err) || d.Attempts+1 >= deliveryMaxAttempts:
+		set["status"] = models.DeliveryFailed
+		set["last_error"] = err.Error()
+		log.Printf("[ERROR] %s delivery %s to user %s failed: %v", d.Channel, d.ID.Hex(), d.UserID, err)
+	default:
+		next := time.Now().Add(deliveryBackoff(d.Attempts + 1))
+		set["next_attempt_at"] = next
+		set["last_error"] = err.Error()
+		log.Printf("[WARN] %s delivery %s to user %s failed (attempt %d), retry at %s: %v",
+			d.Channel, d.ID.Hex(), d.UserID, d.Attempts+1, next.Format(time.RFC3339), err)
+	}
+	if status, ok := set["status"].(string); ok {
+		channelDeliveries.WithLabelValues(d.Channel, status).Inc()
+	}
+
+	_, err = db.Collection(deliveryCollection).UpdateOne(ctx, bson.M{"_id": d.ID}, bson.M{
+		"$set":   set,
+		"$push":  bson.M{"attempt_log": attempt},
+		"$unset": bson.M{"locked_until": "", "locked_by": ""},
+	})
+	if err != nil {
+		log.Printf("[ERROR] Deliveries: failed to record delivery %s: %v", d.ID.Hex(), err)
+	}
+	return true
+}
+
+// sendDelivery renders and sends one delivery. The returned fields record
+// what was sent, whatever the outcome.
+func sendDelivery(ctx context.Context, d *models.Delivery) (bson.M, error) {
+	channel, ok := channels[d.Channel]
+	if !ok {
+		return nil, permanent(fmt.Errorf("channel %s is not configured", d.Channel))
+	}
+
+	var n models.Notification
+	if err := db.Collection(inboxCollection).FindOne(ctx, bson.M{"_id": d.NotificationID}).Decode(&n); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, permanent(fmt.Errorf("notification no longer exists"))
+		}
+		return nil, err
+	}
+
+	contact, err := contacts.lookup(ctx, d.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	lang := templateLanguage(contact.Language)
+	subject, body, err := renderMessage(d.Channel, lang, n, contact.Name)
+	if err != nil {
+		return nil, permanent(err)
+	}
+	update := bson.M{"language": lang, "subject": subject, "body": body}
+	switch d.Channel {
+	case models.ChannelEmail:
+		update["address"] = contact.Email
+	case models.ChannelSMS:
+		update["address"] = contact.Phone
+	}
+
+	link := publicBaseURL()
+	if n.ReportID != "" || n.Type == announcementType {
+		link = reportURL(n)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, deliverySendTimeout)
+	defer cancel()
+	ref, err := channel.Send(sendCtx, contact, Message{
+		DeliveryID: d.ID.Hex(),
+		Subject:    subject,
+		Body:       body,
+		URL:        link,
+	})
+	d.Reference = ref
+	return update, err
+}
+
+// deliveriesHandler exposes delivery status:
+//
+//	GET  /notifications/deliveries              own deliveries (admins: also their department's admin alerts)
+//	POST /notifications/deliveries/{id}/retry   send a failed or skipped delivery again
+func deliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims)
+	if !ok || claims.UserID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/notifications/deliveries"), "/")
+	parts := strings.Split(rest, "/")
+
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		listDeliveries(w, r, claims)
+	case len(parts) == 2 && parts[1] == "retry" && r.Method == http.MethodPost:
+		retryDelivery(w, r, claims, parts[0])
+	default:
+		response.Error(w, http.StatusNotFound, "Not found", "")
+	}
+}
+
+// deliveryScope limits the deliveries a caller may see and retry to their
+// own. Admins also get other admins' deliveries of their department's admin
+// notifications, but never a citizen's: those hold contact details and tie
+// users to their reports.
+func deliveryScope(claims *middleware.UserClaims) bson.M {
+	own := bson.M{"user_id": claims.UserID}
+	if !isAdminRole(claims.Role) {
+		return own
+	}
+	admin := adminCategoryFilter(claims.Department)
+	admin["audience"] = models.AudienceAdmin
+	return bson.M{"$or": []bson.M{own, admin}}
+}
+
+func listDeliveries(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > inboxMaxPageSize {
+		limit = inboxMaxPageSize
+	}
+
+	filter := deliveryScope(claims)
+	if userID := q.Get("user_id"); userID != "" && isAdminRole(claims.Role) {
+		filter["user_id"] = userID
+	}
+	if id := q.Get("notification_id"); id != "" {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid notification ID", err.Error())
+			return
+		}
+		filter["notification_id"] = objID
+	}
+	if status := strings.ToUpper(q.Get("status")); status != "" {
+		filter["status"] = status
+	}
+	if channel := strings.ToLower(q.Get("channel")); channel != "" {
+		filter["channel"] = channel
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	total, err := db.Collection(deliveryCollection).CountDocuments(ctx, filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to count deliveries", err.Error())
+		return
+	}
+	cursor, err := db.Collection(deliveryCollection).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch deliveries", err.Error())
+		return
+	}
+	deliveries := make([]models.Delivery, 0)
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to decode deliveries", err.Error())
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Deliveries fetched successfully", map[string]interface{}{
+		"deliveries": deliveries,
+		"page":       page,
+		"limit":      limit,
+		"total":      total,
+	})
+}
+
+// retryDelivery queues a finished delivery again with a fresh attempt
+// budget, e.g. after the user added a phone number.
+func retryDelivery(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims, id string) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid delivery ID", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	filter := deliveryScope(claims)
+	filter["_id"] = objID
+	filter["status"] = bson.M{"$in": []string{models.DeliveryFailed, models.DeliverySkipped}}
+	var d models.Delivery
+	err = db.Collection(deliveryCollection).FindOneAndUpdate(ctx, filter,
+		bson.M{
+			"$set":   bson.M{"status": models.DeliveryPending, "attempts": 0, "next_attempt_at": time.Now()},
+			"$unset": bson.M{"last_error": ""},
+		}).Decode(&d)
+	if err == mongo.ErrNoDocuments {
+		response.Error(w, http.StatusNotFound, "Delivery not found or not retryable", "")
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to retry delivery", err.Error())
+		return
+	}
+	contacts.forget(d.UserID)
+
+	log.Printf("[INFO] Delivery %s requeued by %s", id, claims.UserID)
+	response.Success(w, http.StatusAccepted, "Delivery queued", map[string]interface{}{"id": id})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/services/notification-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	digestType         = "digest"
+	digestLease        = 10 * time.Minute
+	digestMaxItems     = 10
+	digestScanLimit    = 500
+	digestPollInterval = time.Minute
+)
+
+func startDigestWorker() {
+	ticker := time.NewTicker(digestPollInterval)
+	defer ticker.Stop()
+
+	log.Println("[INFO] Daily digest worker started")
+
+	for range ticker.C {
+		for i := 0; i < 50; i++ {
+			if !sendNextDigest() {
+				break
+			}
+		}
+	}
+}
+
+// sendNextDigest claims one user whose digest is due by pushing its
+// next_digest_at out by a lease, then stores the digest in the inbox and
+// queues it on the digest channel.
+func sendNextDigest() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var p models.Preferences
+	err := db.Collection(preferencesCollection).FindOneAndUpdate(ctx,
+		bson.M{"digest.enabled": true, "next_digest_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"next_digest_at": now.Add(digestLease)}},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "next_digest_at", Value: 1}}),
+	).Decode(&p)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("[ERROR] Digest: failed to claim preferences: %v", err)
+		}
+		return false
+	}
+	p = withDefaults(p)
+
+	from := now.Add(-24 * time.Hour)
+	if p.LastDigestAt != nil {
+		from = *p.LastDigestAt
+	}
+
+	if err := buildDigest(ctx, p, from, now); err != nil {
+		// The lease expires and the digest is retried.
+		log.Printf("[WARN] Digest for user %s failed: %v", p.UserID, err)
+		return true
+	}
+
+	_, err = db.Collection(preferencesCollection).UpdateOne(ctx, bson.M{"_id": p.UserID}, bson.M{
+		"$set": bson.M{"last_digest_at": now, "next_digest_at": nextDigestTime(p, now)},
+	})
+	if err != nil {
+		log.Printf("[ERROR] Digest: failed to reschedule user %s: %v", p.UserID, err)
+	}
+	return true
+}
+
+// buildDigest summarises the notifications the user would have received on
+// email, SMS or push between from and to. Nothing is sent for a quiet day.
+func buildDigest(ctx context.Context, p models.Preferences, from, to time.Time) error {
+	filter := inboxFilter(&middleware.UserClaims{UserID: p.UserID, Role: p.Role, Department: p.Department})
+	filter["created_at"] = bson.M{"$gt": from, "$lte": to}
+	filter["type"] = bson.M{"$ne": digestType}
+
+	cursor, err := db.Collection(inboxCollection).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(digestScanLimit))
+	if err != nil {
+		return err
+	}
+	var found []models.Notification
+	if err := cursor.All(ctx, &found); err != nil {
+		return err
+	}
+
+	summary := &models.DigestSummary{From: from, To: to, Counts: map[string]int{}}
+	for _, n := range found {
+		if !wantsOffline(p, n.Type) {
+			continue
+		}
+		summary.Total++
+		summary.Counts[n.Type]++
+		if len(summary.Items) < digestMaxItems {
+			summary.Items = append(summary.Items, digestItem(n))
+		}
+	}
+	if summary.Total == 0 {
+		return nil
+	}
+
+	n, stored, err := insertNotification(ctx, models.Notification{
+		EventID:   fmt.Sprintf("digest:%s:%d", p.UserID, to.Unix()),
+		Audience:  models.AudienceUser,
+		UserID:    p.UserID,
+		Type:      digestType,
+		Title:     "Ringkasan Notifikasi",
+		Message:   fmt.Sprintf("%d pembaruan sejak ringkasan terakhir", summary.Total),
+		CreatedAt: to,
+		Digest:    summary,
+	})
+	if err != nil {
+		return err
+	}
+	if stored {
+		pushLive(ctx, n)
+		log.Printf("[OK] Digest of %d notification(s) stored for user %s", summary.Total, p.UserID)
+	}
+
+	if !containsString(enabledChannels(), p.Digest.Channel) {
+		return nil
+	}
+	return queueDeliveries(ctx, n, map[string][]string{p.UserID: {p.Digest.Channel}})
+}
+
+// wantsOffline reports whether the type is routed to any channel besides
+// the in-app stream.
+func wantsOffline(p models.Preferences, notificationType string) bool {
+	for _, ch := range channelsFor(p, notificationType) {
+		if ch != models.ChannelInApp {
+			return true
+		}
+	}
+	return false
+}
+
+func digestItem(n models.Notification) models.DigestItem {
+	return models.DigestItem{
+		NotificationID: n.ID.Hex(),
+		ReportID:       n.ReportID,
+		Type:           n.Type,
+		Title:          n.Title,
+		Status:         n.Status,
+		Category:       n.Category,
+		CreatedAt:      n.CreatedAt,
+	}
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"citizen-reporting-system/pkg/queue"
+	"citizen-reporting-system/services/notification-service/models"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Live fan-out across replicas. The shared notifications queue is consumed
+// competitively so each event is stored in the inbox once; the instance that
+// stored it republishes the notification to the live exchange. Every
+// instance has its own exclusive queue on that exchange, bound only to the
+// keys of the users (and admins) currently connected to it, so targeted
+// events reach just the replicas holding a stream for the recipient.
+const (
+	liveExchange       = "notifications.live"
+	adminLiveKey       = "admin"
+	presenceCollection = "presence"
+	presenceTTL        = 90 * time.Second
+	presenceRefresh    = 30 * time.Second
+	liveDedupeWindow   = 10 * time.Minute
+)
+
+var (
+	instanceID    = notificationInstance()
+	liveQueueName atomic.Value // string, current exclusive queue
+	presence      = &presenceRegistry{keys: map[string]int{}}
+	liveSeen      = &dedupeSet{seen: map[string]time.Time{}}
+)
+
+func notificationInstance() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "notification-service"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func userLiveKey(userID string) string { return "user." + userID }
+
+func liveKey(n models.Notification) string {
+	if n.Audience == models.AudienceAdmin {
+		return adminLiveKey
+	}
+	return userLiveKey(n.UserID)
+}
+
+func clientKeys(c *Client) []string {
+	keys := []string{userLiveKey(c.UserID)}
+	if isAdminRole(c.AccessRole) {
+		keys = append(keys, adminLiveKey)
+	}
+	return keys
+}
+
+// presenceRegistry counts local connections per routing key.
+type presenceRegistry struct {
+	mu   sync.Mutex
+	keys map[string]int
+}
+
+// add returns the keys that had no local connection before.
+func (p *presenceRegistry) add(keys ...string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var added []string
+	for _, k := range keys {
+		p.keys[k]++
+		if p.keys[k] == 1 {
+			added = append(added, k)
+		}
+	}
+	return added
+}
+
+// remove returns the keys that no longer have a local connection.
+func (p *presenceRegistry) remove(keys ...string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var removed []string
+	for _, k := range keys {
+		if p.keys[k] == 0 {
+			continue
+		}
+		p.keys[k]--
+		if p.keys[k] == 0 {
+			delete(p.keys, k)
+			removed = append(removed, k)
+		}
+	}
+	return removed
+}
+
+func (p *presenceRegistry) snapshot() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keys := make([]string, 0, len(p.keys))
+	for k := range p.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// dedupeSet remembers notification IDs pushed recently so a redelivered or
+// doubly routed live message is not sent to clients twice.
+type dedupeSet struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func (d *dedupeSet) firstSeen(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	if t, ok := d.seen[id]; ok && now.Sub(t) < liveDedupeWindow {
+		return false
+	}
+	d.seen[id] = now
+	if len(d.seen) > 10000 {
+		for k, t := range d.seen {
+			if now.Sub(t) >= liveDedupeWindow {
+				delete(d.seen, k)
+			}
+		}
+	}
+	return true
+}
+
+func declareLiveExchange(ch *amqp.Channel) error {
+	return queue.DeclareExchange(ch, liveExchange, "topic")
+}
+
+// setupLiveQueue declares this instance's exclusive queue and binds every key
+// with a local connection. It runs again after each reconnect, since the
+// broker drops exclusive queues with the connection.
+func setupLiveQueue(ch *amqp.Channel) (string, error) {
+	name := "notifications.live." + instanceID
+	if _, err := ch.QueueDeclare(name, false, true, true, false, nil); err != nil {
+		return "", err
+	}
+	liveQueueName.Store(name)
+	for _, key := range presence.snapshot() {
+		if err := ch.QueueBind(name, key, liveExchange, false, nil); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+// updateBindings binds or unbinds routing keys on the live queue. Failures
+// are logged only: the queue is rebound from the registry on reconnect.
+func updateBindings(bind, unbind []string) {
+	name, _ := liveQueueName.Load().(string)
+	if name == "" || (len(bind) == 0 && len(unbind) == 0) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ch, err := broker.Channel(ctx)
+	if err != nil {
+		log.Printf("[WARN] Live bindings not updated: %v", err)
+		return
+	}
+	defer ch.Close()
+
+	for _, key := range bind {
+		if err := ch.QueueBind(name, key, liveExchange, false, nil); err != nil {
+			log.Printf("[WARN] Failed to bind %s: %v", key, err)
+			return
+		}
+	}
+	for _, key := range unbind {
+		if err := ch.QueueUnbind(name, key, liveExchange, nil); err != nil {
+			log.Printf("[WARN] Failed to unbind %s: %v", key, err)
+			return
+		}
+	}
+}
+
+type bindingOp struct {
+	bind, unbind []string
+	online       string
+	offline      string
+}
+
+// bindingOps serialises binding and presence changes so a quick reconnect
+// cannot apply its bind before the previous disconnect's unbind.
+var bindingOps = make(chan bindingOp, 1024)
+
+func runBindingWorker() {
+	for op := range bindingOps {
+		updateBindings(op.bind, op.unbind)
+		if op.online != "" {
+			touchPresence(op.online)
+		}
+		if op.offline != "" {
+			clearPresence(op.offline)
+		}
+	}
+}
+
+// clientConnected and clientDisconnected keep the registry, the broker
+// bindings and the shared presence collection in step with local streams.
+// They are called from the hub goroutine.
+func clientConnected(c *Client) {
+	added := presence.add(clientKeys(c)...)
+	if len(added) == 0 {
+		return
+	}
+	op := bindingOp{bind: added}
+	if containsString(added, userLiveKey(c.UserID)) {
+		op.online = c.UserID
+	}
+	bindingOps <- op
+}
+
+func clientDisconnected(c *Client) {
+	removed := presence.remove(clientKeys(c)...)
+	if len(removed) == 0 {
+		return
+	}
+	op := bindingOp{unbind: removed}
+	if containsString(removed, userLiveKey(c.UserID)) {
+		op.offline = c.UserID
+	}
+	bindingOps <- op
+}
+
+func ensurePresenceIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(presenceCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "updated_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32(presenceTTL.Seconds()))},
+	})
+	return err
+}
+
+func touchPresence(userIDs ...string) {
+	if len(userIDs) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(userIDs))
+	for _, id := range userIDs {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": instanceID + ":" + id}).
+			SetUpdate(bson.M{"$set": bson.M{"user_id": id, "instance": instanceID, "updated_at": now}}).
+			SetUpsert(true))
+	}
+	if _, err := db.Collection(presenceCollection).BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		log.Printf("[WARN] Failed to update presence: %v", err)
+	}
+}
+
+func clearPresence(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = db.Collection(presenceCollection).DeleteOne(ctx, bson.M{"_id": instanceID + ":" + userID})
+}
+
+// startPresenceHeartbeat refreshes this instance's presence entries; entries
+// of a crashed instance expire after presenceTTL.
+func startPresenceHeartbeat() {
+	ticker := time.NewTicker(presenceRefresh)
+	defer ticker.Stop()
+	for range ticker.C {
+		var users []string
+		for _, key := range presence.snapshot() {
+			if id, ok := strings.CutPrefix(key, "user."); ok {
+				users = append(users, id)
+			}
+		}
+		touchPresence(users...)
+	}
+}
+
+// userOnline reports whether the user has a live stream on any instance.
+func userOnline(ctx context.Context, userID string) bool {
+	n, err := db.Collection(presenceCollection).CountDocuments(ctx, bson.M{
+		"user_id":    userID,
+		"updated_at": bson.M{"$gt": time.Now().Add(-presenceTTL)},
+	}, options.Count().SetLimit(1))
+	return err == nil && n > 0
+}
+
+// publishLive hands a stored notification to the instances holding a stream
+// for its recipients.
+func publishLive(ctx context.Context, n models.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return broker.Publish(ctx, liveExchange, liveKey(n), false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Transient,
+		MessageId:    n.ID.Hex(),
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+}
+
+func consumeLive() {
+	broker.Consume(context.Background(), queue.ConsumerSpec{
+		Queue:   "notifications.live." + instanceID,
+		Setup:   setupLiveQueue,
+		AutoAck: true,
+	}, func(_ *amqp.Channel, d amqp.Delivery) {
+		if d.Type == livePreferencesType {
+			var p models.Preferences
+			if err := json.Unmarshal(d.Body, &p); err != nil {
+				log.Printf("[WARN] Failed to decode live preferences: %v", err)
+				return
+			}
+			applyPreferences(p)
+			return
+		}
+
+		var n models.Notification
+		if err := json.Unmarshal(d.Body, &n); err != nil {
+			log.Printf("[WARN] Failed to decode live notification: %v", err)
+			return
+		}
+		if !liveSeen.firstSeen(n.ID.Hex()) {
+			return
+		}
+		broadcast <- n
+	})
+}
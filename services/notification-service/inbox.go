@@ -0,0 +1,439 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/events"
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/services/notification-service/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	inboxCollection    = "notifications"
+	countersCollection = "counters"
+	inboxMaxPageSize   = 100
+
+	// followedUpdateType is a status change on a report the user follows
+	// rather than one they filed.
+	followedUpdateType = "followed_update"
+)
+
+var (
+	notificationsStored = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_stored_total",
+		Help: "Notifications written to user inboxes",
+	}, []string{"audience"})
+	sseDropped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "notification_sse_dropped_total",
+		Help: "Live notifications not pushed because the client's buffer was full; they remain in the inbox",
+	})
+)
+
+func registerInboxMetrics() {
+	prometheus.MustRegister(notificationsStored, sseDropped)
+}
+
+func inboxRetention() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("NOTIFICATION_RETENTION")); err == nil && d > 0 {
+		return d
+	}
+	return 90 * 24 * time.Hour
+}
+
+func ensureInboxIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(inboxCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "event_id", Value: 1}, {Key: "audience", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "seq", Value: 1}}},
+		{Keys: bson.D{{Key: "audience", Value: 1}, {Key: "category", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(inboxRetention().Seconds())),
+		},
+	})
+	return err
+}
+
+// adminNotificationTypes are stored once for the admins of the category's
+// department rather than for a single user.
+var adminNotificationTypes = map[string]bool{
+	"new_report":  true,
+	"sla_warning": true,
+	"escalation":  true,
+}
+
+// digestTypes summarise notifications that stay in the inbox on their own.
+var digestTypes = []string{digestType, adminDigestType}
+
+func isAdminRole(role string) bool {
+	return role == "admin" || role == "super-admin"
+}
+
+// adminCategoryFilter limits admin notifications to the admin's department:
+// those addressed to departments must name it, the rest must be in one of
+// its categories. Admins of the general department or without a known one
+// see all of them, as do notifications without a category.
+func adminCategoryFilter(department string) bson.M {
+	allowed := mapDepartmentToCategories(department)
+	if department == "" || len(allowed) == 0 || canonicalDepartment(department) == "general" {
+		return bson.M{}
+	}
+	in := make([]interface{}, 0, len(allowed)+1)
+	for _, c := range allowed {
+		in = append(in, c)
+	}
+	in = append(in, nil)
+	return bson.M{"$or": []bson.M{
+		{"departments": canonicalDepartment(department)},
+		{"departments.0": bson.M{"$exists": false}, "category": bson.M{"$in": in}},
+	}}
+}
+
+// inboxFilter selects the notifications visible to the caller.
+func inboxFilter(claims *middleware.UserClaims) bson.M {
+	or := []bson.M{{"audience": models.AudienceUser, "user_id": claims.UserID}}
+	if isAdminRole(claims.Role) {
+		admin := adminCategoryFilter(claims.Department)
+		admin["audience"] = models.AudienceAdmin
+		or = append(or, admin)
+	}
+	return bson.M{"$or": or, "hidden_for": bson.M{"$ne": claims.UserID}}
+}
+
+// visibleTo is inboxFilter for a single notification held in memory, used to
+// pick the live SSE clients that receive it.
+func visibleTo(n models.Notification, c *Client) bool {
+	switch n.Audience {
+	case models.AudienceUser:
+		return n.UserID != "" && n.UserID == c.UserID
+	case models.AudienceAdmin:
+		if !isAdminRole(c.AccessRole) {
+			return false
+		}
+		allowed := mapDepartmentToCategories(c.Department)
+		if c.Department == "" || len(allowed) == 0 || canonicalDepartment(c.Department) == "general" {
+			return true
+		}
+		if len(n.Departments) > 0 {
+			return containsString(n.Departments, canonicalDepartment(c.Department))
+		}
+		return n.Category == "" || containsString(allowed, n.Category)
+	}
+	return false
+}
+
+// nextSeq hands out increasing notification sequence numbers. Numbers used by
+// redelivered events are skipped, so the sequence can have gaps.
+func nextSeq(ctx context.Context) (int64, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := db.Collection(countersCollection).FindOneAndUpdate(ctx,
+		bson.M{"_id": inboxCollection},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	return counter.Seq, err
+}
+
+// storeNotification writes the event to the inbox. Redelivered events match
+// the existing entry, which is returned with stored false so the caller
+// skips the live push.
+func storeNotification(ctx context.Context, eventID string, event events.Notification) (models.Notification, bool, error) {
+	n := models.Notification{
+		EventID:   eventID,
+		Type:      event.Type,
+		ReportID:  event.ReportID,
+		Title:     event.Title,
+		Message:   event.Message,
+		Status:    event.Status,
+		Category:  event.Category,
+		CreatedAt: event.CreatedAt,
+	}
+	for _, d := range event.Departments {
+		if d = canonicalDepartment(d); d != "" && !containsString(n.Departments, d) {
+			n.Departments = append(n.Departments, d)
+		}
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+
+	switch {
+	case adminNotificationTypes[event.Type]:
+		n.Audience = models.AudienceAdmin
+	case event.UserID != "":
+		n.Audience = models.AudienceUser
+		n.UserID = event.UserID
+	default:
+		return n, false, nil
+	}
+	return insertNotification(ctx, n)
+}
+
+// recipientEvents splits an event addressed to several users into one event
+// per user, stored and delivered independently.
+func recipientEvents(event events.Notification) []events.Notification {
+	if len(event.UserIDs) == 0 {
+		return []events.Notification{event}
+	}
+	out := make([]events.Notification, 0, len(event.UserIDs))
+	seen := map[string]bool{}
+	for _, userID := range event.UserIDs {
+		if userID == "" || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		e := event
+		e.UserID = userID
+		e.UserIDs = nil
+		out = append(out, e)
+	}
+	return out
+}
+
+// insertNotification stores n unless an entry for the same event and
+// recipient exists, in which case that entry is returned with stored false.
+func insertNotification(ctx context.Context, n models.Notification) (models.Notification, bool, error) {
+	seq, err := nextSeq(ctx)
+	if err != nil {
+		return n, false, err
+	}
+	n.Seq = seq
+
+	res, err := db.Collection(inboxCollection).UpdateOne(ctx,
+		bson.M{"event_id": n.EventID, "audience": n.Audience, "user_id": n.UserID},
+		bson.M{"$setOnInsert": n},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return n, false, err
+	}
+	if res.UpsertedID == nil {
+		var existing models.Notification
+		err := db.Collection(inboxCollection).FindOne(ctx,
+			bson.M{"event_id": n.EventID, "audience": n.Audience, "user_id": n.UserID}).Decode(&existing)
+		return existing, false, err
+	}
+	n.ID = res.UpsertedID.(primitive.ObjectID)
+	notificationsStored.WithLabelValues(n.Audience).Inc()
+	return n, true, nil
+}
+
+// inboxHandler serves the caller's inbox:
+//
+//	GET    /notifications/inbox                 list (page, limit, unread=true, view=digests|events)
+//	GET    /notifications/inbox/unread-count
+//	POST   /notifications/inbox/read-all
+//	POST   /notifications/inbox/{id}/read
+//	DELETE /notifications/inbox/{id}
+func inboxHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims)
+	if !ok || claims.UserID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/notifications/inbox"), "/")
+	parts := strings.Split(rest, "/")
+
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		listInbox(w, r, claims)
+	case rest == "unread-count" && r.Method == http.MethodGet:
+		unreadCount(w, r, claims)
+	case rest == "read-all" && r.Method == http.MethodPost:
+		markAllRead(w, r, claims)
+	case len(parts) == 2 && parts[1] == "read" && r.Method == http.MethodPost:
+		markRead(w, r, claims, parts[0])
+	case len(parts) == 1 && rest != "" && r.Method == http.MethodDelete:
+		deleteNotification(w, r, claims, parts[0])
+	default:
+		response.Error(w, http.StatusNotFound, "Not found", "")
+	}
+}
+
+func unreadFilter(claims *middleware.UserClaims) bson.M {
+	filter := inboxFilter(claims)
+	filter["read_by"] = bson.M{"$ne": claims.UserID}
+	return filter
+}
+
+func listInbox(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > inboxMaxPageSize {
+		limit = inboxMaxPageSize
+	}
+
+	filter := inboxFilter(claims)
+	if r.URL.Query().Get("unread") == "true" {
+		filter = unreadFilter(claims)
+	}
+	// Digests and the events they summarise are both kept; view picks one.
+	switch r.URL.Query().Get("view") {
+	case "digests":
+		filter["type"] = bson.M{"$in": digestTypes}
+	case "events":
+		filter["type"] = bson.M{"$nin": digestTypes}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	total, err := db.Collection(inboxCollection).CountDocuments(ctx, filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to count notifications", err.Error())
+		return
+	}
+	unread, err := db.Collection(inboxCollection).CountDocuments(ctx, unreadFilter(claims))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to count notifications", err.Error())
+		return
+	}
+
+	cursor, err := db.Collection(inboxCollection).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch notifications", err.Error())
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var stored []models.Notification
+	if err := cursor.All(ctx, &stored); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to decode notifications", err.Error())
+		return
+	}
+	items := make([]models.Notification, 0, len(stored))
+	for _, n := range stored {
+		items = append(items, n.ForUser(claims.UserID))
+	}
+
+	response.Success(w, http.StatusOK, "Notifications fetched successfully", map[string]interface{}{
+		"notifications": items,
+		"page":          page,
+		"limit":         limit,
+		"total":         total,
+		"unread":        unread,
+	})
+}
+
+func unreadCount(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	unread, err := db.Collection(inboxCollection).CountDocuments(ctx, unreadFilter(claims))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to count notifications", err.Error())
+		return
+	}
+	response.Success(w, http.StatusOK, "Unread count fetched successfully", map[string]interface{}{
+		"unread": unread,
+	})
+}
+
+func markAllRead(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	res, err := db.Collection(inboxCollection).UpdateMany(ctx, unreadFilter(claims),
+		bson.M{"$addToSet": bson.M{"read_by": claims.UserID}})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to mark notifications as read", err.Error())
+		return
+	}
+	response.Success(w, http.StatusOK, "All notifications marked as read", map[string]interface{}{
+		"updated": res.ModifiedCount,
+	})
+}
+
+func markRead(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims, id string) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid notification ID", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	filter := inboxFilter(claims)
+	filter["_id"] = objID
+	res, err := db.Collection(inboxCollection).UpdateOne(ctx, filter,
+		bson.M{"$addToSet": bson.M{"read_by": claims.UserID}})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to mark notification as read", err.Error())
+		return
+	}
+	if res.MatchedCount == 0 {
+		response.Error(w, http.StatusNotFound, "Notification not found", "")
+		return
+	}
+	response.Success(w, http.StatusOK, "Notification marked as read", map[string]interface{}{"id": id})
+}
+
+// deleteNotification removes a user's own notification. Shared admin
+// notifications are only hidden for the caller.
+func deleteNotification(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims, id string) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid notification ID", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	res, err := db.Collection(inboxCollection).DeleteOne(ctx, bson.M{
+		"_id": objID, "audience": models.AudienceUser, "user_id": claims.UserID,
+	})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to delete notification", err.Error())
+		return
+	}
+	if res.DeletedCount == 0 {
+		filter := inboxFilter(claims)
+		filter["_id"] = objID
+		upd, err := db.Collection(inboxCollection).UpdateOne(ctx, filter,
+			bson.M{"$addToSet": bson.M{"hidden_for": claims.UserID}})
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to delete notification", err.Error())
+			return
+		}
+		if upd.MatchedCount == 0 {
+			response.Error(w, http.StatusNotFound, "Notification not found", "")
+			return
+		}
+	}
+
+	log.Printf("[INFO] Notification %s deleted by %s", id, claims.UserID)
+	response.Success(w, http.StatusOK, "Notification deleted", map[string]interface{}{"id": id})
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+
+	AnnouncementScheduled = "SCHEDULED"
+	AnnouncementActive    = "ACTIVE"
+	AnnouncementExpired   = "EXPIRED"
+	AnnouncementCancelled = "CANCELLED"
+)
+
+// AnnouncementArea targets residents either by region code, including its
+// sub-regions, or by a polygon of [lng, lat] points.
+type AnnouncementArea struct {
+	RegionCode string       `bson:"region_code,omitempty" json:"region_code,omitempty"`
+	Polygon    [][2]float64 `bson:"polygon,omitempty" json:"polygon,omitempty"`
+}
+
+// Announcement is an agency notice for the residents of an area, shown in
+// the public feed while valid and sent to each resident once. The fan-out
+// pages through residents in ID order from FanoutCursor.
+type Announcement struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title       string             `bson:"title" json:"title"`
+	Message     string             `bson:"message" json:"message"`
+	Category    string             `bson:"category" json:"category"`
+	Severity    string             `bson:"severity" json:"severity"`
+	Area        AnnouncementArea   `bson:"area" json:"area"`
+	ValidFrom   time.Time          `bson:"valid_from" json:"valid_from"`
+	ValidUntil  time.Time          `bson:"valid_until" json:"valid_until"`
+	Department  string             `bson:"department,omitempty" json:"department,omitempty"`
+	CreatedBy   string             `bson:"created_by" json:"created_by,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	CancelledAt *time.Time         `bson:"cancelled_at,omitempty" json:"cancelled_at,omitempty"`
+
+	FanoutCursor string     `bson:"fanout_cursor,omitempty" json:"-"`
+	FanoutDone   bool       `bson:"fanout_done" json:"fanout_done"`
+	Recipients   int        `bson:"recipients" json:"recipients"`
+	LockedUntil  *time.Time `bson:"locked_until,omitempty" json:"-"`
+	LockedBy     string     `bson:"locked_by,omitempty" json:"-"`
+
+	Status string `bson:"-" json:"status"`
+}
+
+// WithStatus fills in Status as of now.
+func (a Announcement) WithStatus(now time.Time) Announcement {
+	switch {
+	case a.CancelledAt != nil:
+		a.Status = AnnouncementCancelled
+	case now.Before(a.ValidFrom):
+		a.Status = AnnouncementScheduled
+	case !now.Before(a.ValidUntil):
+		a.Status = AnnouncementExpired
+	default:
+		a.Status = AnnouncementActive
+	}
+	return a
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+
+	DeliveryPending = "PENDING"
+	DeliverySent    = "SENT"
+	DeliveryFailed  = "FAILED"
+	DeliverySkipped = "SKIPPED"
+)
+
+type DeliveryAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	Reference  string    `bson:"reference,omitempty" json:"reference,omitempty"`
+	DurationMs int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+// Delivery is one notification sent to one user over one channel, retried
+// by the delivery worker until it is sent, skipped or out of attempts.
+type Delivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	NotificationID primitive.ObjectID `bson:"notification_id" json:"notification_id"`
+	UserID         string             `bson:"user_id" json:"user_id"`
+	Audience       string             `bson:"audience,omitempty" json:"audience,omitempty"`
+	Category       string             `bson:"category,omitempty" json:"category,omitempty"`
+	Departments    []string           `bson:"departments,omitempty" json:"departments,omitempty"`
+	Channel        string             `bson:"channel" json:"channel"`
+	Status         string             `bson:"status" json:"status"`
+	Language       string             `bson:"language,omitempty" json:"language,omitempty"`
+	Address        string             `bson:"address,omitempty" json:"address,omitempty"`
+	Subject        string             `bson:"subject,omitempty" json:"subject,omitempty"`
+	Body           string             `bson:"body,omitempty" json:"body,omitempty"`
+	Reference      string             `bson:"reference,omitempty" json:"reference,omitempty"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	AttemptLog     []DeliveryAttempt  `bson:"attempt_log,omitempty" json:"attempt_log,omitempty"`
+	LastError      string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	NextAttemptAt  time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil    *time.Time         `bson:"locked_until,omitempty" json:"-"`
+	LockedBy       string             `bson:"locked_by,omitempty" json:"-"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	SentAt         *time.Time         `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+	TraceContext   map[string]string  `bson:"trace_context,omitempty" json:"-"`
+}
+
+// PushSubscription is a browser's Web Push endpoint (the PushSubscription
+// JSON from the Push API).
+type PushSubscription struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    string             `bson:"user_id" json:"-"`
+	Endpoint  string             `bson:"endpoint" json:"endpoint"`
+	P256dh    string             `bson:"p256dh" json:"-"`
+	Auth      string             `bson:"auth" json:"-"`
+	UserAgent string             `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	LastUsed  *time.Time         `bson:"last_used,omitempty" json:"last_used,omitempty"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AudienceUser  = "user"
+	AudienceAdmin = "admin"
+)
+
+// Notification is one entry in a user's inbox. Citizen notifications belong
+// to a single user; admin notifications are stored once and shown to every
+// admin whose department covers the category, so read and delete state is
+// kept per user in ReadBy and HiddenFor. Seq increases with every stored
+// notification and is the SSE event ID clients resume from; it is taken
+// before the insert, so notifications can commit slightly out of seq order.
+type Notification struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Seq       int64              `bson:"seq" json:"seq"`
+	EventID   string             `bson:"event_id" json:"event_id"`
+	Audience  string             `bson:"audience" json:"audience"`
+	UserID    string             `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Type      string             `bson:"type" json:"type"`
+	ReportID  string             `bson:"report_id" json:"report_id"`
+	Title     string             `bson:"title" json:"title"`
+	Message   string             `bson:"message" json:"message"`
+	Status    string             `bson:"status" json:"status"`
+	Category  string             `bson:"category,omitempty" json:"category,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	// Departments addresses an admin notification to these departments
+	// instead of by category.
+	Departments []string `bson:"departments,omitempty" json:"departments,omitempty"`
+	// Severity is set on "announcement" notifications.
+	Severity string `bson:"severity,omitempty" json:"severity,omitempty"`
+	// Digest is set on "digest" notifications summarising others.
+	Digest    *DigestSummary `bson:"digest,omitempty" json:"digest,omitempty"`
+	ReadBy    []string       `bson:"read_by,omitempty" json:"-"`
+	HiddenFor []string       `bson:"hidden_for,omitempty" json:"-"`
+	// DeliveredTo lists users whose WebSocket client acknowledged it.
+	DeliveredTo []string `bson:"delivered_to,omitempty" json:"-"`
+
+	Read bool `bson:"-" json:"read"`
+}
+
+// ForUser fills in the per-user read flag.
+func (n Notification) ForUser(userID string) Notification {
+	n.Read = false
+	for _, id := range n.ReadBy {
+		if id == userID {
+			n.Read = true
+			break
+		}
+	}
+	return n
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChannelInApp is the inbox's live push over SSE and WebSocket. Every
+// notification is still stored in the inbox; this only controls the push.
+const ChannelInApp = "in_app"
+
+// Preference events a user can route to channels.
+const (
+	EventStatusChange    = "status_change"
+	EventUpvoteMilestone = "upvote_milestone"
+	EventFollowedReport  = "followed_report"
+	EventAnnouncement    = "announcement"
+	EventNewReport       = "new_report"
+	EventSLAWarning      = "sla_warning"
+	EventEscalation      = "escalation"
+)
+
+// QuietHours holds back SMS and push between Start and End ("HH:MM", local
+// to the preference's timezone). A window may cross midnight.
+type QuietHours struct {
+	Enabled bool   `bson:"enabled" json:"enabled"`
+	Start   string `bson:"start" json:"start"`
+	End     string `bson:"end" json:"end"`
+}
+
+// DigestSettings replaces immediate email, SMS and push with one summary a
+// day, sent at Hour over Channel.
+type DigestSettings struct {
+	Enabled bool   `bson:"enabled" json:"enabled"`
+	Hour    int    `bson:"hour" json:"hour"`
+	Channel string `bson:"channel" json:"channel"`
+}
+
+// Preferences are a user's notification settings. Role and department are
+// copied from the token on save so workers can resolve the admin inbox.
+type Preferences struct {
+	UserID       string              `bson:"_id" json:"user_id"`
+	Role         string              `bson:"role,omitempty" json:"-"`
+	Department   string              `bson:"department,omitempty" json:"-"`
+	Events       map[string][]string `bson:"events" json:"events"`
+	QuietHours   QuietHours          `bson:"quiet_hours" json:"quiet_hours"`
+	Digest       DigestSettings      `bson:"digest" json:"digest"`
+	Timezone     string              `bson:"timezone" json:"timezone"`
+	NextDigestAt *time.Time          `bson:"next_digest_at,omitempty" json:"next_digest_at,omitempty"`
+	LastDigestAt *time.Time          `bson:"last_digest_at,omitempty" json:"last_digest_at,omitempty"`
+	UpdatedAt    time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// DigestItem is one notification summarised in a digest.
+type DigestItem struct {
+	NotificationID string    `bson:"notification_id" json:"notification_id"`
+	ReportID       string    `bson:"report_id,omitempty" json:"report_id,omitempty"`
+	Type           string    `bson:"type" json:"type"`
+	Title          string    `bson:"title" json:"title"`
+	Status         string    `bson:"status,omitempty" json:"status,omitempty"`
+	Category       string    `bson:"category,omitempty" json:"category,omitempty"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+}
+
+// DigestSummary is the body of a digest notification.
+type DigestSummary struct {
+	From   time.Time      `bson:"from" json:"from"`
+	To     time.Time      `bson:"to" json:"to"`
+	Total  int            `bson:"total" json:"total"`
+	Counts map[string]int `bson:"counts" json:"counts"`
+	Items  []DigestItem   `bson:"items" json:"items"`
+}
+
+// AdminDigestBucket gathers one department's batched admin notifications
+// during one window; the admin digest worker turns it into a single
+// notification once FlushAt passes.
+type AdminDigestBucket struct {
+	ID              string               `bson:"_id"`
+	Department      string               `bson:"department"`
+	WindowStart     time.Time            `bson:"window_start"`
+	FlushAt         time.Time            `bson:"flush_at"`
+	NotificationIDs []primitive.ObjectID `bson:"notification_ids"`
+	Total           int                  `bson:"total"`
+	Counts          map[string]int       `bson:"counts"`
+	Items           []DigestItem         `bson:"items"`
+	Flushed         bool                 `bson:"flushed"`
+	FlushedAt       *time.Time           `bson:"flushed_at,omitempty"`
+	LockedUntil     *time.Time           `bson:"locked_until,omitempty"`
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/services/notification-service/models"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	preferencesCollection = "preferences"
+
+	// livePreferencesType marks live messages that carry a user's updated
+	// preferences rather than a notification.
+	livePreferencesType = "preferences"
+)
+
+var (
+	citizenEvents = []string{models.EventStatusChange, models.EventUpvoteMilestone, models.EventFollowedReport, models.EventAnnouncement}
+	adminEvents   = []string{models.EventNewReport, models.EventSLAWarning, models.EventEscalation}
+	allChannels   = []string{models.ChannelInApp, models.ChannelEmail, models.ChannelSMS, models.ChannelPush}
+
+	// Channels quiet hours hold back; email and the in-app stream are silent.
+	quietChannels = []string{models.ChannelSMS, models.ChannelPush}
+
+	defaultEventChannels = map[string][]string{
+		models.EventStatusChange:    {models.ChannelInApp, models.ChannelEmail, models.ChannelSMS, models.ChannelPush},
+		models.EventUpvoteMilestone: {models.ChannelInApp},
+		models.EventFollowedReport:  {models.ChannelInApp, models.ChannelPush},
+		models.EventAnnouncement:    {models.ChannelInApp, models.ChannelPush},
+		models.EventNewReport:       {models.ChannelInApp},
+		models.EventSLAWarning:      {models.ChannelInApp, models.ChannelEmail},
+		models.EventEscalation:      {models.ChannelInApp, models.ChannelEmail},
+	}
+)
+
+func defaultTimezone() string {
+	if v := strings.TrimSpace(os.Getenv("NOTIFY_DEFAULT_TIMEZONE")); v != "" {
+		return v
+	}
+	return "Asia/Jakarta"
+}
+
+// preferenceEvent maps a notification type to the preference event that
+// controls it; types without one are only pushed in-app.
+func preferenceEvent(notificationType string) string {
+	switch notificationType {
+	case "status_update":
+		return models.EventStatusChange
+	case followedUpdateType:
+		return models.EventFollowedReport
+	}
+	return notificationType
+}
+
+func eventsFor(role string) []string {
+	if isAdminRole(role) {
+		return append(append([]string{}, citizenEvents...), adminEvents...)
+	}
+	return citizenEvents
+}
+
+func defaultPreferences(userID string) models.Preferences {
+	events := map[string][]string{}
+	for event, channels := range defaultEventChannels {
+		events[event] = channels
+	}
+	return models.Preferences{
+		UserID:     userID,
+		Events:     events,
+		QuietHours: models.QuietHours{Start: "22:00", End: "06:00"},
+		Digest:     models.DigestSettings{Hour: 18, Channel: models.ChannelEmail},
+		Timezone:   defaultTimezone(),
+	}
+}
+
+// channelsFor lists the channels a notification type goes to. In-app is
+// the only channel for types the preferences do not cover.
+func channelsFor(p models.Preferences, notificationType string) []string {
+	event := preferenceEvent(notificationType)
+	if channels, ok := p.Events[event]; ok {
+		return channels
+	}
+	if channels, ok := defaultEventChannels[event]; ok {
+		return channels
+	}
+	return []string{models.ChannelInApp}
+}
+
+func preferenceLocation(p models.Preferences) *time.Location {
+	if loc, err := time.LoadLocation(p.Timezone); err == nil {
+		return loc
+	}
+	loc, err := time.LoadLocation(defaultTimezone())
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(v))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// quietUntil returns when the user's quiet hours end if now falls inside
+// them, or the zero time otherwise.
+func quietUntil(p models.Preferences, now time.Time) time.Time {
+	if !p.QuietHours.Enabled {
+		return time.Time{}
+	}
+	from, err1 := parseClock(p.QuietHours.Start)
+	to, err2 := parseClock(p.QuietHours.End)
+	if err1 != nil || err2 != nil || from == to {
+		return time.Time{}
+	}
+
+	local := now.In(preferenceLocation(p))
+	minute := local.Hour()*60 + local.Minute()
+	in := minute >= from && minute < to
+	if from > to {
+		in = minute >= from || minute < to
+	}
+	if !in {
+		return time.Time{}
+	}
+
+	end := time.Date(local.Year(), local.Month(), local.Day(), to/60, to%60, 0, 0, local.Location())
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// nextDigestTime is the next occurrence of the digest hour after now.
+func nextDigestTime(p models.Preferences, now time.Time) time.Time {
+	local := now.In(preferenceLocation(p))
+	next := time.Date(local.Year(), local.Month(), local.Day(), p.Digest.Hour, 0, 0, 0, local.Location())
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func ensurePreferenceIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(preferencesCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "digest.enabled", Value: 1}, {Key: "next_digest_at", Value: 1}}},
+	})
+	return err
+}
+
+// loadPreferences returns the user's preferences, or the defaults if none
+// were saved.
+func loadPreferences(ctx context.Context, userID string) (models.Preferences, error) {
+	var p models.Preferences
+	err := db.Collection(preferencesCollection).FindOne(ctx, bson.M{"_id": userID}).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return defaultPreferences(userID), nil
+	}
+	if err != nil {
+		return defaultPreferences(userID), err
+	}
+	return withDefaults(p), nil
+}
+
+// loadPreferencesMany is loadPreferences for several users in one query.
+func loadPreferencesMany(ctx context.Context, userIDs []string) (map[string]models.Preferences, error) {
+	out := make(map[string]models.Preferences, len(userIDs))
+	for _, id := range userIDs {
+		out[id] = defaultPreferences(id)
+	}
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+	cursor, err := db.Collection(preferencesCollection).Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return out, err
+	}
+	var saved []models.Preferences
+	if err := cursor.All(ctx, &saved); err != nil {
+		return out, err
+	}
+	for _, p := range saved {
+		out[p.UserID] = withDefaults(p)
+	}
+	return out, nil
+}
+
+// withDefaults fills events added since the preferences were saved.
+func withDefaults(p models.Preferences) models.Preferences {
+	if p.Events == nil {
+		p.Events = map[string][]string{}
+	}
+	for event, channels := range defaultEventChannels {
+		if _, ok := p.Events[event]; !ok {
+			p.Events[event] = channels
+		}
+	}
+	if p.Timezone == "" {
+		p.Timezone = defaultTimezone()
+	}
+	return p
+}
+
+func validatePreferences(p *models.Preferences, role string) error {
+	allowed := eventsFor(role)
+	for event, channels := range p.Events {
+		if !containsString(allowed, event) {
+			return fmt.Errorf("unknown event %q", event)
+		}
+		seen := map[string]bool{}
+		clean := make([]string, 0, len(channels))
+		for _, ch := range channels {
+			ch = strings.ToLower(strings.TrimSpace(ch))
+			if !containsString(allChannels, ch) {
+				return fmt.Errorf("event %s: unknown channel %q", event, ch)
+			}
+			if !seen[ch] {
+				seen[ch] = true
+				clean = append(clean, ch)
+			}
+		}
+		p.Events[event] = clean
+	}
+
+	if p.QuietHours.Enabled {
+		if _, err := parseClock(p.QuietHours.Start); err != nil {
+			return fmt.Errorf("quiet_hours.start must be HH:MM")
+		}
+		if _, err := parseClock(p.QuietHours.End); err != nil {
+			return fmt.Errorf("quiet_hours.end must be HH:MM")
+		}
+	}
+
+	if p.Digest.Hour < 0 || p.Digest.Hour > 23 {
+		return fmt.Errorf("digest.hour must be between 0 and 23")
+	}
+	if p.Digest.Channel == "" {
+		p.Digest.Channel = models.ChannelEmail
+	}
+	if p.Digest.Channel == models.ChannelInApp || !containsString(allChannels, p.Digest.Channel) {
+		return fmt.Errorf("digest.channel must be email, sms or push")
+	}
+
+	if p.Timezone == "" {
+		p.Timezone = defaultTimezone()
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", p.Timezone)
+	}
+	return nil
+}
+
+// preferencesHandler serves the caller's notification preferences:
+//
+//	GET /notifications/preferences
+//	PUT /notifications/preferences
+func preferencesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims)
+	if !ok || claims.UserID == "" {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		getPreferences(w, r, claims)
+	case http.MethodPut:
+		putPreferences(w, r, claims)
+	default:
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+func preferencesPayload(p models.Preferences, role string) map[string]interface{} {
+	events := map[string][]string{}
+	for _, event := range eventsFor(role) {
+		events[event] = channelsFor(p, event)
+	}
+	p.Events = events
+	return map[string]interface{}{
+		"preferences": p,
+		"events":      eventsFor(role),
+		"channels":    allChannels,
+	}
+}
+
+func getPreferences(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, err := loadPreferences(ctx, claims.UserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch preferences", err.Error())
+		return
+	}
+	response.Success(w, http.StatusOK, "Preferences fetched successfully", preferencesPayload(p, claims.Role))
+}
+
+func putPreferences(w http.ResponseWriter, r *http.Request, claims *middleware.UserClaims) {
+	var input models.Preferences
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if err := validatePreferences(&input, claims.Role); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error(), "")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	p := withDefaults(models.Preferences{
+		UserID:     claims.UserID,
+		Role:       claims.Role,
+		Department: claims.Department,
+		Events:     input.Events,
+		QuietHours: input.QuietHours,
+		Digest:     input.Digest,
+		Timezone:   input.Timezone,
+		UpdatedAt:  now,
+	})
+	set := bson.M{
+		"role":        p.Role,
+		"department":  p.Department,
+		"events":      p.Events,
+		"quiet_hours": p.QuietHours,
+		"digest":      p.Digest,
+		"timezone":    p.Timezone,
+		"updated_at":  now,
+	}
+	update := bson.M{"$set": set}
+	if p.Digest.Enabled {
+		next := nextDigestTime(p, now)
+		set["next_digest_at"] = next
+		// The first digest covers what arrived since it was switched on.
+		update["$setOnInsert"] = bson.M{"last_digest_at": now}
+	} else {
+		update["$unset"] = bson.M{"next_digest_at": ""}
+	}
+
+	err := db.Collection(preferencesCollection).FindOneAndUpdate(ctx, bson.M{"_id": claims.UserID}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&p)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to save preferences", err.Error())
+		return
+	}
+	if p.Digest.Enabled && p.LastDigestAt == nil {
+		_, _ = db.Collection(preferencesCollection).UpdateOne(ctx, bson.M{"_id": p.UserID},
+			bson.M{"$set": bson.M{"last_digest_at": now}})
+		p.LastDigestAt = &now
+	}
+	p = withDefaults(p)
+
+	if err := publishPreferences(ctx, p); err != nil {
+		log.Printf("[WARN] Failed to publish preferences of user %s: %v", p.UserID, err)
+		applyPreferences(p)
+	}
+
+	log.Printf("[INFO] Notification preferences updated by %s", claims.UserID)
+	response.Success(w, http.StatusOK, "Preferences saved", preferencesPayload(p, claims.Role))
+}
+
+// publishPreferences sends updated preferences to the instances holding a
+// stream for the user, which are exactly those bound to the user's key.
+func publishPreferences(ctx context.Context, p models.Preferences) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return broker.Publish(ctx, liveExchange, userLiveKey(p.UserID), false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Transient,
+		Type:         livePreferencesType,
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+}
+
+// applyPreferences updates the user's local streams.
+func applyPreferences(p models.Preferences) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for client := range clients {
+		if client.UserID == p.UserID {
+			client.setPreferences(p)
+		}
+	}
+}
+
+func (c *Client) setPreferences(p models.Preferences) {
+	c.mu.Lock()
+	c.prefs = &p
+	c.mu.Unlock()
+}
+
+// allowsInApp reports whether the user wants n pushed to open streams.
+// Clients whose preferences could not be loaded get everything but the
+// events batched into admin digests.
+func (c *Client) allowsInApp(n models.Notification) bool {
+	if batchedForAdminDigest(n) {
+		return false
+	}
+	c.mu.Lock()
+	p := c.prefs
+	c.mu.Unlock()
+	if p == nil || n.Type == "digest" {
+		return true
+	}
+	return containsString(notificationChannels(*p, n), models.ChannelInApp)
+}
+
+// clientPreferences loads preferences for a stream about to register.
+func clientPreferences(ctx context.Context, userID string) *models.Preferences {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	p, err := loadPreferences(ctx, userID)
+	if err != nil {
+		log.Printf("[WARN] Failed to load preferences of user %s: %v", userID, err)
+		return nil
+	}
+	return &p
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"citizen-reporting-system/services/notification-service/models"
+)
+
+func TestQuietUntil(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hh, mm int) time.Time { return time.Date(2025, 3, day, hh, mm, 0, 0, jakarta) }
+	prefs := func(enabled bool, start, end string) models.Preferences {
+		return models.Preferences{
+			Timezone:   "Asia/Jakarta",
+			QuietHours: models.QuietHours{Enabled: enabled, Start: start, End: end},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		prefs models.Preferences
+		now   time.Time
+		want  time.Time
+	}{
+		{"disabled", prefs(false, "22:00", "06:00"), at(10, 23, 0), time.Time{}},
+		{"daytime window inside", prefs(true, "13:00", "15:00"), at(10, 14, 0), at(10, 15, 0)},
+		{"daytime window start is inclusive", prefs(true, "13:00", "15:00"), at(10, 13, 0), at(10, 15, 0)},
+		{"daytime window end is exclusive", prefs(true, "13:00", "15:00"), at(10, 15, 0), time.Time{}},
+		{"overnight before midnight ends next day", prefs(true, "22:00", "06:00"), at(10, 23, 30), at(11, 6, 0)},
+		{"overnight at start", prefs(true, "22:00", "06:00"), at(10, 22, 0), at(11, 6, 0)},
+		{"overnight after midnight ends same day", prefs(true, "22:00", "06:00"), at(11, 5, 59), at(11, 6, 0)},
+		{"overnight at midnight", prefs(true, "22:00", "06:00"), at(11, 0, 0), at(11, 6, 0)},
+		{"overnight end is exclusive", prefs(true, "22:00", "06:00"), at(11, 6, 0), time.Time{}},
+		{"outside overnight window", prefs(true, "22:00", "06:00"), at(10, 12, 0), time.Time{}},
+		{"empty window", prefs(true, "22:00", "22:00"), at(10, 22, 0), time.Time{}},
+		{"unparseable clock", prefs(true, "10pm", "06:00"), at(10, 23, 0), time.Time{}},
+		{"now in another zone", prefs(true, "22:00", "06:00"), time.Date(2025, 3, 10, 16, 30, 0, 0, time.UTC), at(11, 6, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := quietUntil(tt.prefs, tt.now)
+			if !got.Equal(tt.want) {
+				t.Errorf("quietUntil() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextDigestTime(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hh, mm int) time.Time { return time.Date(2025, 3, day, hh, mm, 0, 0, jakarta) }
+
+	tests := []struct {
+		name string
+		hour int
+		now  time.Time
+		want time.Time
+	}{
+		{"later today", 18, at(10, 9, 0), at(10, 18, 0)},
+		{"exactly at the hour rolls over", 18, at(10, 18, 0), at(11, 18, 0)},
+		{"just past the hour", 18, at(10, 18, 1), at(11, 18, 0)},
+		{"midnight digest late evening", 0, at(10, 23, 59), at(11, 0, 0)},
+		{"early digest across month end", 6, at(31, 22, 0), time.Date(2025, 4, 1, 6, 0, 0, 0, jakarta)},
+		{"local day differs from UTC", 7, time.Date(2025, 3, 10, 23, 30, 0, 0, time.UTC), at(11, 7, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := models.Preferences{Timezone: "Asia/Jakarta", Digest: models.DigestSettings{Enabled: true, Hour: tt.hour}}
+			got := nextDigestTime(p, tt.now)
+			if !got.Equal(tt.want) {
+				t.Errorf("nextDigestTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"citizen-reporting-system/pkg/queue"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	notificationDLQ = "notifications_dlq"
+	attemptHeader   = "x-attempt"
+)
+
+var (
+	notificationMaxAttempts = envInt("NOTIFICATION_MAX_ATTEMPTS", 5)
+	notificationRetryBase   = envDuration("NOTIFICATION_RETRY_BASE", 2*time.Second)
+	notificationRetryMax    = envDuration("NOTIFICATION_RETRY_MAX", 5*time.Minute)
+)
+
+// notificationRetryDelay is the wait before delivery number attempt+1.
+func notificationRetryDelay(attempt int) time.Duration {
+	d := notificationRetryBase
+	for i := 1; i < attempt && d < notificationRetryMax; i++ {
+		d *= 2
+	}
+	if d > notificationRetryMax {
+		d = notificationRetryMax
+	}
+	return d
+}
+
+// notificationRetryQueue includes the TTL so changing the backoff declares
+// new queues instead of failing on mismatched arguments.
+func notificationRetryQueue(delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", notificationQueue, delay.Milliseconds())
+}
+
+// declareNotificationRetryQueues creates the DLQ and one delay queue per
+// backoff step; expired messages are dead-lettered back onto the
+// notifications queue through the default exchange.
+func declareNotificationRetryQueues(ch *amqp.Channel) error {
+	if err := queue.DeclareQueue(ch, notificationDLQ, nil); err != nil {
+		return err
+	}
+	for attempt := 1; attempt < notificationMaxAttempts; attempt++ {
+		delay := notificationRetryDelay(attempt)
+		err := queue.DeclareQueue(ch, notificationRetryQueue(delay), amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": notificationQueue,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryNotification schedules d for another attempt, or parks it in the DLQ
+// once the attempts are used up. The delivery is acked only after the copy
+// is published; otherwise it is requeued.
+func retryNotification(d amqp.Delivery, cause error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	attempt := headerInt(d.Headers, attemptHeader) + 1
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[attemptHeader] = int32(attempt)
+	headers["x-last-error"] = cause.Error()
+
+	// The routing key is lost on republish; legacy events are typed by it.
+	msgType := d.Type
+	if msgType == "" {
+		msgType = d.RoutingKey
+	}
+
+	target := notificationDLQ
+	if attempt < notificationMaxAttempts {
+		target = notificationRetryQueue(notificationRetryDelay(attempt))
+	} else {
+		headers["x-failed-at"] = time.Now().Format(time.RFC3339)
+	}
+
+	err := broker.Publish(ctx, "", target, false, amqp.Publishing{
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    d.MessageId,
+		Type:         msgType,
+		Timestamp:    d.Timestamp,
+		Headers:      headers,
+		Body:         d.Body,
+	})
+	if err != nil {
+		log.Printf("[ERROR] Failed to schedule notification retry, requeueing: %v", err)
+		time.Sleep(time.Second)
+		_ = d.Nack(false, true)
+		return
+	}
+	_ = d.Ack(false)
+
+	if target == notificationDLQ {
+		log.Printf("[ERROR] Notification %s moved to %s after %d attempt(s): %v", d.MessageId, notificationDLQ, attempt, cause)
+		return
+	}
+	log.Printf("[WARN] Notification %s failed (attempt %d/%d), retrying: %v", d.MessageId, attempt, notificationMaxAttempts, cause)
+}
+
+func headerInt(headers amqp.Table, key string) int {
+	switch v := headers[key].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/services/notification-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	sseHeartbeat   = envDuration("SSE_HEARTBEAT_INTERVAL", 20*time.Second)
+	sseRetry       = envDuration("SSE_RETRY", 3*time.Second)
+	sseReplayLimit = envInt("SSE_REPLAY_LIMIT", 100)
+	// sseReplayOverlap is how far below the resume point replay starts.
+	sseReplayOverlap = envInt("SSE_REPLAY_OVERLAP", 50)
+)
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// lastEventID reads the resume point. Browsers send the Last-Event-ID header
+// on automatic reconnects; clients that reconnect by hand pass last_event_id.
+func lastEventID(r *http.Request) int64 {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// replaySince pages through the notifications stored after seq, oldest
+// first, sseReplayLimit at a time, and hands each to emit until it returns
+// false. A seq is taken before its insert, so a notification can commit after
+// one with a higher seq; replay starts sseReplayOverlap below the resume
+// point to pick those up, and clients drop IDs they already hold.
+func replaySince(ctx context.Context, claims *middleware.UserClaims, seq int64, emit func(models.Notification) bool) (int, error) {
+	from := seq - int64(sseReplayOverlap)
+	if from < 0 {
+		from = 0
+	}
+	filter := inboxFilter(claims)
+
+	replayed := 0
+	for {
+		filter["seq"] = bson.M{"$gt": from}
+		cursor, err := db.Collection(inboxCollection).Find(ctx, filter, options.Find().
+			SetSort(bson.D{{Key: "seq", Value: 1}}).
+			SetLimit(int64(sseReplayLimit)))
+		if err != nil {
+			return replayed, err
+		}
+		var page []models.Notification
+		if err := cursor.All(ctx, &page); err != nil {
+			return replayed, err
+		}
+		for _, n := range page {
+			from = n.Seq
+			replayed++
+			if !emit(n) {
+				return replayed, nil
+			}
+		}
+		if len(page) < sseReplayLimit {
+			return replayed, nil
+		}
+	}
+}
+
+// deliveredIDs remembers the notifications a stream has sent, so one that
+// arrives both by replay and live goes out once. Seqs cannot serve here as
+// they do not commit in order. Only the latest few are kept.
+type deliveredIDs struct {
+	seen  map[primitive.ObjectID]struct{}
+	order []primitive.ObjectID
+}
+
+const deliveredIDsKept = 1024
+
+func newDeliveredIDs() *deliveredIDs {
+	return &deliveredIDs{seen: map[primitive.ObjectID]struct{}{}}
+}
+
+// add records id and reports whether it was new.
+func (d *deliveredIDs) add(id primitive.ObjectID) bool {
+	if _, ok := d.seen[id]; ok {
+		return false
+	}
+	d.seen[id] = struct{}{}
+	d.order = append(d.order, id)
+	if len(d.order) > deliveredIDsKept {
+		delete(d.seen, d.order[0])
+		d.order = d.order[1:]
+	}
+	return true
+}
+
+func writeSSE(w http.ResponseWriter, n models.Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", n.Seq, data)
+	return err
+}
+
+func subscribeHandler(w http.ResponseWriter, r *http.Request) {
+	tokenString := r.URL.Query().Get("token")
+	if tokenString == "" {
+		authHeader := r.Header.Get("Authorization")
+		if strings.HasPrefix(authHeader, "Bearer ") {
+			tokenString = strings.TrimPrefix(authHeader, "Bearer ")
+		}
+	}
+
+	if tokenString == "" {
+		http.Error(w, "Unauthorized: Missing token", http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := validateToken(tokenString)
+	if err != nil {
+		log.Printf("[WARN] Invalid token attempt: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Last-Event-ID")
+
+	client := &Client{
+		UserID:     claims.UserID,
+		AccessRole: claims.Role,
+		Department: claims.Department,
+		Send:       make(chan models.Notification, 64),
+		prefs:      clientPreferences(r.Context(), claims.UserID),
+	}
+
+	// Register before replaying so nothing stored in between is missed;
+	// live events already covered by the replay are skipped by ID.
+	register <- client
+	defer func() {
+		unregister <- client
+	}()
+
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	fmt.Fprintf(w, "data: %s\n\n", `{"type":"connected","message":"Connection established"}`)
+
+	delivered := newDeliveredIDs()
+	if lastSeq := lastEventID(r); lastSeq > 0 {
+		writeFailed := false
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		replayed, err := replaySince(ctx, claims, lastSeq, func(n models.Notification) bool {
+			if !delivered.add(n.ID) || !client.allowsInApp(n) {
+				return true
+			}
+			if err := writeSSE(w, n.ForUser(claims.UserID)); err != nil {
+				writeFailed = true
+				return false
+			}
+			return true
+		})
+		cancel()
+		if writeFailed {
+			return
+		}
+		if err != nil {
+			log.Printf("[WARN] SSE replay for user %s failed: %v", claims.UserID, err)
+		}
+		if replayed > 0 {
+			log.Printf("[INFO] SSE replayed %d notification(s) to user %s", replayed, claims.UserID)
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	// The stream is authorised by the token it was opened with, so it ends
+	// when that token does; the client reconnects with a fresh one.
+	var expired <-chan time.Time
+	if claims.ExpiresAt != nil {
+		timer := time.NewTimer(time.Until(claims.ExpiresAt.Time))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case n, ok := <-client.Send:
+			if !ok {
+				return
+			}
+			if !delivered.add(n.ID) {
+				continue
+			}
+			if err := writeSSE(w, n); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprintf(w, ": heartbeat %d\n\n", time.Now().Unix()); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case <-expired:
+			fmt.Fprintf(w, "event: token_expired\ndata: %s\n\n", `{"type":"token_expired","message":"Session expired"}`)
+			flusher.Flush()
+			log.Printf("[INFO] SSE stream for user %s closed: token expired", claims.UserID)
+			return
+		}
+	}
+}
@@ -0,0 +1,465 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+
+	"citizen-reporting-system/services/notification-service/models"
+)
+
+const defaultLanguage = "id"
+
+// templateSources[language][channel][notification type] = {subject, body}.
+// Subjects are the email subject and the push title; SMS has none. The
+// "default" entry covers types without their own template.
+var templateSources = map[string]map[string]map[string][2]string{
+	"id": {
+		models.ChannelEmail: {
+			"new_report": {
+				`[Laporan Warga] Laporan baru: {{.Category}}`,
+				`Halo {{.Name}},
+
+{{.Message}}
+
+Buka dasbor: {{.AdminURL}}`,
+			},
+			"sla_warning": {
+				`[Laporan Warga] {{.Title}}: {{.Category}}`,
+				`Halo {{.Name}},
+
+{{.Message}}
+
+Laporan yang melewati tenggat SLA akan dieskalasi otomatis.
+
+Buka dasbor: {{.AdminURL}}`,
+			},
+			"escalation": {
+				`[Laporan Warga] Laporan dieskalasi: {{.Category}}`,
+				`Halo {{.Name}},
+
+{{.Message}}
+
+Buka dasbor: {{.AdminURL}}`,
+			},
+			"admin_digest": {
+				`[Laporan Warga] {{.Title}}`,
+				`Halo {{.Name}},
+
+{{.Message}}:
+{{range $type, $count := .Digest.Counts}}
+- {{typeLabel $type}}: {{$count}}{{end}}
+
+Laporan teratas:
+{{range .Digest.Items}}
+- {{typeLabel .Type}}{{if .Category}} ({{.Category}}){{end}}: {{.Title}}{{end}}
+{{if gt .Digest.Total (len .Digest.Items)}}
+...dan {{sub .Digest.Total (len .Digest.Items)}} lainnya.
+{{end}}
+Buka dasbor: {{.AdminURL}}
+
+Salam,
+Sistem Laporan Warga`,
+			},
+			"digest": {
+				`[Laporan Warga] Ringkasan notifikasi: {{.Digest.Total}} pembaruan`,
+				`Halo {{.Name}},
+
+Ada {{.Digest.Total}} pembaruan sejak ringkasan terakhir:
+{{range .Digest.Items}}
+- {{typeLabel .Type}}{{if .Category}} ({{.Category}}){{end}}: {{.Title}}{{if .Status}} - {{statusLabel .Status}}{{end}}{{end}}
+{{if gt .Digest.Total (len .Digest.Items)}}
+...dan {{sub .Digest.Total (len .Digest.Items)}} lainnya.
+{{end}}
+Lihat semua notifikasi: {{.BaseURL}}
+
+Salam,
+Sistem Laporan Warga`,
+			},
+			"status_update": {
+				`[Laporan Warga] Status laporan Anda: {{.StatusLabel}}`,
+				`Halo {{.Name}},
+
+Status laporan Anda telah diperbarui menjadi: {{.StatusLabel}}.
+{{if .Message}}
+{{.Message}}
+{{end}}
+Lihat detail laporan: {{.ReportURL}}
+
+Salam,
+Sistem Laporan Warga`,
+			},
+			"followed_update": {
+				`[Laporan Warga] {{.Title}}`,
+				`Halo {{.Name}},
+
+Laporan yang Anda ikuti kini berstatus: {{.StatusLabel}}.
+
+{{.Message}}
+
+Lihat laporan: {{.ReportURL}}
+
+Anda menerima email ini karena mengikuti laporan tersebut. Berhenti mengikuti dari halaman laporan.
+
+Salam,
+Sistem Laporan Warga`,
+			},
+			"announcement": {
+				`[Laporan Warga] {{if eq .Severity "critical"}}PENTING: {{end}}{{.Title}}`,
+				`Halo {{.Name}},
+
+Pengumuman untuk wilayah Anda ({{severityLabel .Severity}}, {{.Category}}):
+
+{{.Message}}
+
+Lihat pengumuman lain: {{.ReportURL}}
+
+Salam,
+Sistem Laporan Warga`,
+			},
+			"default": {
+				`[Laporan Warga] {{.Title}}`,
+				`Halo {{.Name}},
+
+{{.Message}}
+
+{{.ReportURL}}
+
+Salam,
+Sistem Laporan Warga`,
+			},
+		},
+		models.ChannelSMS: {
+			"new_report":      {"", `Laporan Warga: laporan baru ({{.Category}}). {{.AdminURL}}`},
+			"sla_warning":     {"", `Laporan Warga: {{.Message}} {{.AdminURL}}`},
+			"escalation":      {"", `Laporan Warga: laporan {{.Category}} dieskalasi. {{.AdminURL}}`},
+			"digest":          {"", `Laporan Warga: {{.Digest.Total}} pembaruan sejak ringkasan terakhir. {{.BaseURL}}`},
+			"admin_digest":    {"", `Laporan Warga: {{.Message}}. {{.AdminURL}}`},
+			"status_update":   {"", `Laporan Warga: status laporan Anda kini {{.StatusLabel}}. Detail: {{.ReportURL}}`},
+			"followed_update": {"", `Laporan Warga: laporan yang Anda ikuti kini {{.StatusLabel}}. {{.ReportURL}}`},
+			"announcement":    {"", `Laporan Warga - {{.Title}}: {{.Message}}`},
+			"default":         {"", `Laporan Warga: {{.Title}}. {{.Message}}`},
+		},
+		models.ChannelPush: {
+			"sla_warning":     {`{{.Title}}`, `Laporan {{.Category}} mendekati tenggat SLA`},
+			"digest":          {`Ringkasan Notifikasi`, `{{.Digest.Total}} pembaruan sejak ringkasan terakhir`},
+			"status_update":   {`{{.Title}}`, `Status laporan Anda: {{.StatusLabel}}`},
+			"followed_update": {`{{.Title}}`, `{{.Message}}`},
+			"announcement":    {`{{.Title}}`, `{{.Message}}`},
+			"default":         {`{{.Title}}`, `{{.Message}}`},
+		},
+	},
+	"en": {
+		models.ChannelEmail: {
+			"new_report": {
+				`[Citizen Reports] New report: {{.Category}}`,
+				`Hello {{.Name}},
+
+A new {{.Category}} report was submitted for your department.
+
+Open the dashboard: {{.AdminURL}}`,
+			},
+			"sla_warning": {
+				`[Citizen Reports] SLA warning: {{.Category}} report`,
+				`Hello {{.Name}},
+
+A {{.Category}} report assigned to your department has used most of its SLA window. It is escalated automatically once the deadline passes.
+
+Open the dashboard: {{.AdminURL}}`,
+			},
+			"escalation": {
+				`[Citizen Reports] Report escalated: {{.Category}}`,
+				`Hello {{.Name}},
+
+A {{.Category}} report for your department has been escalated.
+
+Open the dashboard: {{.AdminURL}}`,
+			},
+			"admin_digest": {
+				`[Citizen Reports] Department digest: {{.Digest.Total}} notifications`,
+				`Hello {{.Name}},
+
+Your department received {{.Digest.Total}} notifications in the last window:
+{{range $type, $count := .Digest.Counts}}
+- {{typeLabel $type}}: {{$count}}{{end}}
+
+Top reports:
+{{range .Digest.Items}}
+- {{typeLabel .Type}}{{if .Category}} ({{.Category}}){{end}}: {{.Title}}{{end}}
+{{if gt .Digest.Total (len .Digest.Items)}}
+...and {{sub .Digest.Total (len .Digest.Items)}} more.
+{{end}}
+Open the dashboard: {{.AdminURL}}
+
+Regards,
+Citizen Reporting System`,
+			},
+			"digest": {
+				`[Citizen Reports] Notification digest: {{.Digest.Total}} updates`,
+				`Hello {{.Name}},
+
+There are {{.Digest.Total}} updates since your last digest:
+{{range .Digest.Items}}
+- {{typeLabel .Type}}{{if .Category}} ({{.Category}}){{end}}{{if .Status}}: {{statusLabel .Status}}{{end}}{{end}}
+{{if gt .Digest.Total (len .Digest.Items)}}
+...and {{sub .Digest.Total (len .Digest.Items)}} more.
+{{end}}
+See all notifications: {{.BaseURL}}
+
+Regards,
+Citizen Reporting System`,
+			},
+			"status_update": {
+				`[Citizen Reports] Your report is now {{.StatusLabel}}`,
+				`Hello {{.Name}},
+
+The status of your report has been updated to: {{.StatusLabel}}.
+
+View the report: {{.ReportURL}}
+
+Regards,
+Citizen Reporting System`,
+			},
+			"followed_update": {
+				`[Citizen Reports] A report you follow is now {{.StatusLabel}}`,
+				`Hello {{.Name}},
+
+A report you follow has been updated to: {{.StatusLabel}}.
+
+View the report: {{.ReportURL}}
+
+You receive this because you follow the report. Unfollow it from the report page.
+
+Regards,
+Citizen Reporting System`,
+			},
+			"announcement": {
+				`[Citizen Reports] {{if eq .Severity "critical"}}IMPORTANT: {{end}}{{.Title}}`,
+				`Hello {{.Name}},
+
+An announcement for your area ({{severityLabel .Severity}}, {{.Category}}):
+
+{{.Message}}
+
+See other announcements: {{.ReportURL}}
+
+Regards,
+Citizen Reporting System`,
+			},
+			"default": {
+				`[Citizen Reports] Update on your report`,
+				`Hello {{.Name}},
+
+There is an update on your report.
+
+{{.ReportURL}}
+
+Regards,
+Citizen Reporting System`,
+			},
+		},
+		models.ChannelSMS: {
+			"new_report":      {"", `Citizen Reports: new {{.Category}} report. {{.AdminURL}}`},
+			"sla_warning":     {"", `Citizen Reports: a {{.Category}} report is nearing its SLA deadline. {{.AdminURL}}`},
+			"escalation":      {"", `Citizen Reports: a {{.Category}} report was escalated. {{.AdminURL}}`},
+			"digest":          {"", `Citizen Reports: {{.Digest.Total}} updates since your last digest. {{.BaseURL}}`},
+			"admin_digest":    {"", `Citizen Reports: {{.Digest.Total}} new notifications for your department. {{.AdminURL}}`},
+			"status_update":   {"", `Citizen Reports: your report is now {{.StatusLabel}}. Details: {{.ReportURL}}`},
+			"followed_update": {"", `Citizen Reports: a report you follow is now {{.StatusLabel}}. {{.ReportURL}}`},
+			"announcement":    {"", `Citizen Reports - {{.Title}}: {{.Message}}`},
+			"default":         {"", `Citizen Reports: there is an update on your report. {{.ReportURL}}`},
+		},
+		models.ChannelPush: {
+			"new_report":      {`New report`, `New {{.Category}} report for your department`},
+			"sla_warning":     {`SLA warning`, `A {{.Category}} report is nearing its SLA deadline`},
+			"escalation":      {`Report escalated`, `A {{.Category}} report was escalated`},
+			"digest":          {`Notification digest`, `{{.Digest.Total}} updates since your last digest`},
+			"admin_digest":    {`Department digest`, `{{.Digest.Total}} new notifications for your department`},
+			"status_update":   {`Report status updated`, `Your report is now {{.StatusLabel}}`},
+			"followed_update": {`Followed report updated`, `A report you follow is now {{.StatusLabel}}`},
+			"announcement":    {`{{.Title}}`, `{{.Message}}`},
+			"default":         {`Report update`, `There is an update on your report`},
+		},
+	},
+}
+
+var statusLabels = map[string]map[string]string{
+	"id": {
+		"PENDING":     "Menunggu",
+		"DISPATCHED":  "Diteruskan ke Dinas",
+		"IN_PROGRESS": "Sedang Diproses",
+		"RESOLVED":    "Selesai",
+		"REJECTED":    "Ditolak",
+	},
+	"en": {
+		"PENDING":     "Pending",
+		"DISPATCHED":  "Forwarded to the department",
+		"IN_PROGRESS": "In progress",
+		"RESOLVED":    "Resolved",
+		"REJECTED":    "Rejected",
+	},
+}
+
+var typeLabels = map[string]map[string]string{
+	"id": {
+		"status_update":   "Pembaruan status",
+		"followed_update": "Laporan yang diikuti",
+		"announcement":    "Pengumuman",
+		"new_report":      "Laporan baru",
+		"sla_warning":     "Peringatan SLA",
+		"escalation":      "Eskalasi",
+		"admin_digest":    "Ringkasan departemen",
+	},
+	"en": {
+		"status_update":   "Status update",
+		"followed_update": "Followed report",
+		"announcement":    "Announcement",
+		"new_report":      "New report",
+		"sla_warning":     "SLA warning",
+		"escalation":      "Escalation",
+		"admin_digest":    "Department digest",
+	},
+}
+
+var severityLabels = map[string]map[string]string{
+	"id": {
+		models.SeverityInfo:     "informasi",
+		models.SeverityWarning:  "peringatan",
+		models.SeverityCritical: "penting",
+	},
+	"en": {
+		models.SeverityInfo:     "information",
+		models.SeverityWarning:  "warning",
+		models.SeverityCritical: "critical",
+	},
+}
+
+func templateFuncs(lang string) template.FuncMap {
+	return template.FuncMap{
+		"statusLabel": func(status string) string {
+			if label := statusLabels[lang][status]; label != "" {
+				return label
+			}
+			return status
+		},
+		"typeLabel": func(t string) string {
+			if label := typeLabels[lang][t]; label != "" {
+				return label
+			}
+			return t
+		},
+		"severityLabel": func(severity string) string {
+			if label := severityLabels[lang][severity]; label != "" {
+				return label
+			}
+			return severity
+		},
+		"sub": func(a, b int) int { return a - b },
+	}
+}
+
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+var messageTemplates = parseTemplates()
+
+func parseTemplates() map[string]map[string]map[string]messageTemplate {
+	out := map[string]map[string]map[string]messageTemplate{}
+	for lang, channels := range templateSources {
+		out[lang] = map[string]map[string]messageTemplate{}
+		for channel, types := range channels {
+			out[lang][channel] = map[string]messageTemplate{}
+			for typ, src := range types {
+				name := lang + "/" + channel + "/" + typ
+				funcs := templateFuncs(lang)
+				out[lang][channel][typ] = messageTemplate{
+					subject: template.Must(template.New(name + "/subject").Funcs(funcs).Parse(src[0])),
+					body:    template.Must(template.New(name + "/body").Funcs(funcs).Parse(src[1])),
+				}
+			}
+		}
+	}
+	return out
+}
+
+type templateData struct {
+	models.Notification
+	Name        string
+	StatusLabel string
+	ReportURL   string
+	BaseURL     string
+	AdminURL    string
+}
+
+func publicBaseURL() string {
+	if v := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"); v != "" {
+		return v
+	}
+	return "http://localhost"
+}
+
+// reportURL links to where the recipient sees the report: the dashboard for
+// admins, the public feed for followers and announcements and otherwise the
+// citizen's own report list, with the report highlighted.
+func reportURL(n models.Notification) string {
+	switch {
+	case n.Audience == models.AudienceAdmin:
+		return publicBaseURL() + "/admin/"
+	case n.Type == followedUpdateType:
+		return publicBaseURL() + "/feed?report=" + url.QueryEscape(n.ReportID)
+	case n.Type == announcementType:
+		return publicBaseURL() + "/feed"
+	}
+	return publicBaseURL() + "/my-reports?report=" + url.QueryEscape(n.ReportID)
+}
+
+func templateLanguage(lang string) string {
+	if _, ok := templateSources[lang]; ok {
+		return lang
+	}
+	return defaultLanguage
+}
+
+// renderMessage picks the template for the channel, language and
+// notification type, falling back to the type's default and to Indonesian.
+func renderMessage(channel, lang string, n models.Notification, name string) (subject, body string, err error) {
+	lang = templateLanguage(lang)
+	tmpls := messageTemplates[lang][channel]
+	t, ok := tmpls[n.Type]
+	if !ok {
+		t, ok = tmpls["default"]
+	}
+	if !ok {
+		return "", "", fmt.Errorf("no %s template for %s", channel, lang)
+	}
+
+	label := statusLabels[lang][n.Status]
+	if label == "" {
+		label = n.Status
+	}
+	if name == "" {
+		name = map[string]string{"id": "Warga", "en": "there"}[lang]
+	}
+	data := templateData{
+		Notification: n,
+		Name:         name,
+		StatusLabel:  label,
+		ReportURL:    reportURL(n),
+		BaseURL:      publicBaseURL(),
+		AdminURL:     publicBaseURL() + "/admin/",
+	}
+
+	var sb, bb bytes.Buffer
+	if err := t.subject.Execute(&sb, data); err != nil {
+		return "", "", err
+	}
+	if err := t.body.Execute(&bb, data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(sb.String()), strings.TrimSpace(bb.String()), nil
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/services/notification-service/models"
+
+	"github.com/gorilla/websocket"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	wsWriteWait      = 10 * time.Second
+	wsMaxMessageSize = 4096
+)
+
+var (
+	wsPingInterval = envDuration("WS_PING_INTERVAL", 25*time.Second)
+	wsPongWait     = wsPingInterval * 2
+
+	// Streams authenticate with a bearer token rather than cookies, so a
+	// cross-origin page cannot open one on a user's behalf; any origin is
+	// accepted, as for SSE.
+	wsUpgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     func(*http.Request) bool { return true },
+	}
+)
+
+// wsInbound is a client command:
+//
+//	{"action":"subscribe","report_ids":["..."],"departments":["..."]}
+//	{"action":"unsubscribe","report_ids":["..."],"departments":["..."]}
+//	{"action":"ack","ids":["<notification id>"]}
+//	{"action":"ping"}
+type wsInbound struct {
+	Action      string   `json:"action"`
+	ReportIDs   []string `json:"report_ids,omitempty"`
+	Departments []string `json:"departments,omitempty"`
+	IDs         []string `json:"ids,omitempty"`
+}
+
+type wsOutbound struct {
+	Type        string               `json:"type"`
+	Seq         int64                `json:"seq,omitempty"`
+	Data        *models.Notification `json:"data,omitempty"`
+	ReportIDs   []string             `json:"report_ids,omitempty"`
+	Departments []string             `json:"departments,omitempty"`
+	Acked       int64                `json:"acked,omitempty"`
+	Message     string               `json:"message,omitempty"`
+}
+
+func wsNotification(n models.Notification) wsOutbound {
+	return wsOutbound{Type: "notification", Seq: n.Seq, Data: &n}
+}
+
+// wsHandler serves /notifications/ws. It registers with the same hub as
+// SSE; the connection's goroutine is the only writer, and a reader
+// goroutine forwards replies to it.
+func wsHandler(w http.ResponseWriter, r *http.Request) {
+	tokenString := r.URL.Query().Get("token")
+	if tokenString == "" {
+		tokenString = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if tokenString == "" {
+		http.Error(w, "Unauthorized: Missing token", http.StatusUnauthorized)
+		return
+	}
+	claims, err := validateToken(tokenString)
+	if err != nil {
+		log.Printf("[WARN] Invalid token attempt: %v", err)
+		http.Error(w, "Unauthorized: Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("[WARN] WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	client := &Client{
+		UserID:     claims.UserID,
+		AccessRole: claims.Role,
+		Department: claims.Department,
+		Send:       make(chan models.Notification, 64),
+		prefs:      clientPreferences(r.Context(), claims.UserID),
+	}
+	register <- client
+	defer func() {
+		unregister <- client
+	}()
+
+	replies := make(chan wsOutbound, 16)
+	done := make(chan struct{})
+	go wsReadLoop(conn, client, claims, replies, done)
+
+	write := func(msg wsOutbound) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteJSON(msg) == nil
+	}
+
+	if !write(wsOutbound{Type: "connected", Message: "Connection established"}) {
+		return
+	}
+
+	delivered := newDeliveredIDs()
+	if lastSeq := lastEventID(r); lastSeq > 0 {
+		writeFailed := false
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		_, err := replaySince(ctx, claims, lastSeq, func(n models.Notification) bool {
+			if !delivered.add(n.ID) || !client.allowsInApp(n) {
+				return true
+			}
+			if !write(wsNotification(n.ForUser(claims.UserID))) {
+				writeFailed = true
+				return false
+			}
+			return true
+		})
+		cancel()
+		if writeFailed {
+			return
+		}
+		if err != nil {
+			log.Printf("[WARN] WebSocket replay for user %s failed: %v", claims.UserID, err)
+		}
+	}
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	var expired <-chan time.Time
+	if claims.ExpiresAt != nil {
+		timer := time.NewTimer(time.Until(claims.ExpiresAt.Time))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+
+		case n, ok := <-client.Send:
+			if !ok {
+				return
+			}
+			if !delivered.add(n.ID) {
+				continue
+			}
+			if !write(wsNotification(n)) {
+				return
+			}
+
+		case msg := <-replies:
+			if !write(msg) {
+				return
+			}
+
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+
+		case <-expired:
+			write(wsOutbound{Type: "token_expired", Message: "Session expired"})
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "token expired"),
+				time.Now().Add(wsWriteWait))
+			log.Printf("[INFO] WebSocket for user %s closed: token expired", claims.UserID)
+			return
+		}
+	}
+}
+
+// wsReadLoop handles client commands until the connection fails or stops
+// answering pings.
+func wsReadLoop(conn *websocket.Conn, client *Client, claims *middleware.UserClaims, replies chan<- wsOutbound, done chan<- struct{}) {
+	defer close(done)
+
+	conn.SetReadLimit(wsMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	reply := func(msg wsOutbound) {
+		select {
+		case replies <- msg:
+		default:
+		}
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Printf("[WARN] WebSocket for user %s closed: %v", client.UserID, err)
+			}
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var in wsInbound
+		if err := json.Unmarshal(data, &in); err != nil {
+			reply(wsOutbound{Type: "error", Message: "invalid message"})
+			continue
+		}
+
+		switch in.Action {
+		case "subscribe", "unsubscribe":
+			if len(in.Departments) > 0 && !isAdminRole(client.AccessRole) {
+				reply(wsOutbound{Type: "error", Message: "department subscriptions require an admin account"})
+				continue
+			}
+			reports, departments := client.updateSubscriptions(in.Action == "subscribe", in.ReportIDs, in.Departments)
+			reply(wsOutbound{Type: "subscriptions", ReportIDs: reports, Departments: departments})
+
+		case "ack":
+			acked, err := ackNotifications(claims, in.IDs)
+			if err != nil {
+				reply(wsOutbound{Type: "error", Message: "ack failed: " + err.Error()})
+				continue
+			}
+			reply(wsOutbound{Type: "ack", Acked: acked})
+
+		case "ping":
+			reply(wsOutbound{Type: "pong"})
+
+		default:
+			reply(wsOutbound{Type: "error", Message: "unknown action " + in.Action})
+		}
+	}
+}
+
+// ackNotifications records that the caller received the notifications.
+func ackNotifications(claims *middleware.UserClaims, ids []string) (int64, error) {
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	if len(objIDs) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := inboxFilter(claims)
+	filter["_id"] = bson.M{"$in": objIDs}
+	res, err := db.Collection(inboxCollection).UpdateMany(ctx, filter,
+		bson.M{"$addToSet": bson.M{"delivered_to": claims.UserID}})
+	if err != nil {
+		return 0, err
+	}
+	return res.MatchedCount, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// updateSubscriptions adds or removes report and department filters and
+// returns the resulting sets.
+func (c *Client) updateSubscriptions(add bool, reportIDs, departments []string) ([]string, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reports == nil {
+		c.reports = map[string]bool{}
+		c.departments = map[string]bool{}
+	}
+	for _, id := range reportIDs {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		if add {
+			c.reports[id] = true
+		} else {
+			delete(c.reports, id)
+		}
+	}
+	for _, d := range departments {
+		if d = normalizeDepartment(d); d == "" {
+			continue
+		}
+		if add {
+			c.departments[d] = true
+		} else {
+			delete(c.departments, d)
+		}
+	}
+	return sortedKeys(c.reports), sortedKeys(c.departments)
+}
+
+// wants applies the client's subscriptions on top of visibleTo. A client
+// without subscriptions receives everything it may see.
+func (c *Client) wants(n models.Notification) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.reports) == 0 && len(c.departments) == 0 {
+		return true
+	}
+	if c.reports[n.ReportID] {
+		return true
+	}
+	for d := range c.departments {
+		if n.Category != "" && containsString(mapDepartmentToCategories(d), n.Category) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/services/report-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	auditCollection     = "audit_log"
+	auditMaxPageSize    = 500
+	auditMaxExportCount = 50000
+)
+
+func ensureAuditIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(auditCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "seq", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"seq": bson.M{"$gt": 0}}),
+		},
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "report_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "report_ids", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "action", Value: 1}, {Key: "timestamp", Value: -1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(auditCheckpointCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "seq", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func auditActor(r *http.Request) (string, string, string) {
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims)
+	if !ok || claims == nil {
+		return "anonymous", "", ""
+	}
+	return claims.UserID, claims.Role, claims.Department
+}
+
+// recordAudit appends one entry per report ID to the hash chain. The audit
+// log is insert-only: nothing in this service updates or deletes entries.
+func recordAudit(r *http.Request, action string, reportIDs []string, details map[string]string) {
+	actorID, role, department := auditActor(r)
+	writeAudit(middleware.GetTraceID(r), actorID, role, department, action, reportIDs, details)
+}
+
+func recordSystemAudit(actor, action, reportID string, details map[string]string) {
+	writeAudit("", actor, "system", "", action, []string{reportID}, details)
+}
+
+func writeAudit(traceID, actorID, role, department, action string, reportIDs []string, details map[string]string) {
+	if len(reportIDs) == 0 {
+		reportIDs = []string{""}
+	}
+
+	now := time.Now().UTC()
+	entries := make([]models.AuditEntry, 0, len(reportIDs))
+	for _, id := range reportIDs {
+		entries = append(entries, models.AuditEntry{
+			Timestamp:  now,
+			ActorID:    actorID,
+			ActorRole:  role,
+			Department: department,
+			ReportID:   id,
+			Action:     action,
+			TraceID:    traceID,
+			Details:    details,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := enqueueAuditEntries(ctx, entries); err != nil {
+		middleware.LogError(traceID, fmt.Sprintf("Failed to write audit log (%s)", action), err)
+	}
+}
+
+// auditReadReports records a list read as a single entry naming every report
+// returned, so a page of results costs one chain append rather than one per
+// report.
+func auditReadReports(r *http.Request, reports []models.Report) {
+	if len(reports) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(reports))
+	for _, report := range reports {
+		ids = append(ids, report.ID.Hex())
+	}
+
+	actorID, role, department := auditActor(r)
+	traceID := middleware.GetTraceID(r)
+	entry := models.AuditEntry{
+		Timestamp:  time.Now().UTC(),
+		ActorID:    actorID,
+		ActorRole:  role,
+		Department: department,
+		Action:     models.AuditActionRead,
+		TraceID:    traceID,
+		Details:    map[string]string{"path": r.URL.Path},
+	}
+	if len(ids) == 1 {
+		entry.ReportID = ids[0]
+	} else {
+		entry.ReportIDs = ids
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := enqueueAuditEntries(ctx, []models.AuditEntry{entry}); err != nil {
+		middleware.LogError(traceID, fmt.Sprintf("Failed to write audit log (%s)", models.AuditActionRead), err)
+	}
+}
+
+func parseAuditTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+func buildAuditFilter(r *http.Request) (bson.M, error) {
+	q := r.URL.Query()
+	filter := bson.M{}
+
+	for param, field := range map[string]string{
+		"actor_id":   "actor_id",
+		"department": "department",
+		"action":     "action",
+		"trace_id":   "trace_id",
+	} {
+		if v := strings.TrimSpace(q.Get(param)); v != "" {
+			filter[field] = v
+		}
+	}
+
+	if v := strings.TrimSpace(q.Get("report_id")); v != "" {
+		filter["$or"] = []bson.M{{"report_id": v}, {"report_ids": v}}
+	}
+
+	timeRange := bson.M{}
+	if v := strings.TrimSpace(q.Get("from")); v != "" {
+		t, err := parseAuditTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from: %w", err)
+		}
+		timeRange["$gte"] = t
+	}
+	if v := strings.TrimSpace(q.Get("to")); v != "" {
+		t, err := parseAuditTime(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to: %w", err)
+		}
+		timeRange["$lte"] = t
+	}
+	if len(timeRange) > 0 {
+		filter["timestamp"] = timeRange
+	}
+
+	return filter, nil
+}
+
+func adminAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	filter, err := buildAuditFilter(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > auditMaxPageSize {
+		limit = auditMaxPageSize
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	total, err := db.Collection(auditCollection).CountDocuments(ctx, filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to count audit entries", err.Error())
+		return
+	}
+
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := db.Collection(auditCollection).Find(ctx, filter, findOpts)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch audit log", err.Error())
+		return
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]models.AuditEntry, 0)
+	if err := cursor.All(ctx, &entries); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to decode audit log", err.Error())
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Audit log fetched successfully", map[string]interface{}{
+		"entries": entries,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
+
+func adminAuditExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	filter, err := buildAuditFilter(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		response.Error(w, http.StatusBadRequest, "Unsupported export format", "format must be csv or json")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: "seq", Value: 1}, {Key: "timestamp", Value: 1}}).
+		SetLimit(auditMaxExportCount)
+	cursor, err := db.Collection(auditCollection).Find(ctx, filter, findOpts)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to export audit log", err.Error())
+		return
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]models.AuditEntry, 0)
+	if err := cursor.All(ctx, &entries); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to decode audit log", err.Error())
+		return
+	}
+
+	recordAudit(r, models.AuditActionExport, nil, map[string]string{
+		"format": format,
+		"query":  r.URL.RawQuery,
+		"count":  strconv.Itoa(len(entries)),
+	})
+
+	filename := fmt.Sprintf("audit-log-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		response.JSON(w, http.StatusOK, entries)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"seq", "id", "timestamp", "actor_id", "actor_role", "department", "report_id", "action", "trace_id", "details", "prev_hash", "hash"})
+	for _, e := range entries {
+		_ = cw.Write([]string{
+			strconv.FormatInt(e.Seq, 10),
+			e.ID.Hex(),
+			e.Timestamp.UTC().Format(time.RFC3339Nano),
+			e.ActorID,
+			e.ActorRole,
+			e.Department,
+			strings.TrimSpace(e.ReportID + " " + strings.Join(e.ReportIDs, " ")),
+			e.Action,
+			e.TraceID,
+			formatAuditDetails(e.Details),
+			e.PrevHash,
+			e.Hash,
+		})
+	}
+	cw.Flush()
+}
+
+func formatAuditDetails(details map[string]string) string {
+	keys := make([]string, 0, len(details))
+	for k := range details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+details[k])
+	}
+	return strings.Join(parts, ";")
+}
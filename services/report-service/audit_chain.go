@@ -0,0 +1,637 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/services/report-service/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	auditCheckpointCollection = "audit_checkpoints"
+	auditHeadCollection       = "audit_heads"
+	auditAppendRetries        = 5
+	auditBatchSize            = 200
+)
+
+var (
+	// The chain head is only touched by the appender goroutine.
+	auditHeadSeq  int64
+	auditHeadHash string
+	auditHeadOK   bool
+
+	auditQueue = make(chan models.AuditEntry, auditQueueSize())
+
+	auditSigningKey ed25519.PrivateKey
+
+	auditAppended = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "audit_entries_appended_total",
+		Help: "Audit entries linked onto the chain",
+	})
+	auditAppendErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "audit_append_errors_total",
+		Help: "Failed audit chain insert attempts",
+	})
+	auditDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "audit_entries_dropped_total",
+		Help: "Audit entries that never reached the chain",
+	}, []string{"reason"})
+	auditQueueDepth = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "audit_queue_depth",
+		Help: "Audit entries waiting for the appender",
+	}, func() float64 { return float64(len(auditQueue)) })
+)
+
+func auditQueueSize() int {
+	if v, err := strconv.Atoi(os.Getenv("AUDIT_QUEUE_SIZE")); err == nil && v > 0 {
+		return v
+	}
+	return 10000
+}
+
+func registerAuditMetrics() {
+	prometheus.MustRegister(auditAppended, auditAppendErrors, auditDropped, auditQueueDepth)
+}
+
+func auditCheckpointInterval() int64 {
+	if v, err := strconv.ParseInt(os.Getenv("AUDIT_CHECKPOINT_INTERVAL"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return 100
+}
+
+func auditHeadInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("AUDIT_CHECKPOINT_EVERY")); err == nil && d > 0 {
+		return d
+	}
+	return time.Hour
+}
+
+// loadAuditSigningKey reads the Ed25519 seed from AUDIT_SIGNING_KEY (hex).
+// The key must be provisioned; there is no fallback. If AUDIT_PUBLIC_KEY is
+// pinned too, it has to belong to the seed.
+func loadAuditSigningKey() error {
+	seed, err := hex.DecodeString(strings.TrimSpace(os.Getenv("AUDIT_SIGNING_KEY")))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return errors.New("AUDIT_SIGNING_KEY must be a 32-byte hex Ed25519 seed")
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	if pinned, err := auditPinnedPublicKey(""); err == nil && !pinned.Equal(key.Public()) {
+		return errors.New("AUDIT_SIGNING_KEY does not match AUDIT_PUBLIC_KEY")
+	}
+	auditSigningKey = key
+	return nil
+}
+
+// auditPinnedPublicKey is the key checkpoints are verified against: the one
+// given by the auditor, else AUDIT_PUBLIC_KEY. The server's own signing key
+// is never trusted for verification.
+func auditPinnedPublicKey(given string) (ed25519.PublicKey, error) {
+	if strings.TrimSpace(given) == "" {
+		given = os.Getenv("AUDIT_PUBLIC_KEY")
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(given))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("a pinned 32-byte hex Ed25519 public key is required (AUDIT_PUBLIC_KEY or public_key)")
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+func auditKeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// computeAuditHash hashes every stored field except _id and hash itself.
+// Timestamps are truncated to milliseconds so the value survives a BSON
+// round trip unchanged.
+func computeAuditHash(e models.AuditEntry) string {
+	if len(e.Details) == 0 {
+		e.Details = nil
+	}
+	canonical := struct {
+		Seq        int64             `json:"seq"`
+		PrevHash   string            `json:"prev_hash"`
+		Timestamp  string            `json:"timestamp"`
+		ActorID    string            `json:"actor_id"`
+		ActorRole  string            `json:"actor_role"`
+		Department string            `json:"department"`
+		ReportID   string            `json:"report_id"`
+		ReportIDs  []string          `json:"report_ids,omitempty"`
+		Action     string            `json:"action"`
+		TraceID    string            `json:"trace_id"`
+		Details    map[string]string `json:"details"`
+	}{
+		Seq:        e.Seq,
+		PrevHash:   e.PrevHash,
+		Timestamp:  e.Timestamp.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano),
+		ActorID:    e.ActorID,
+		ActorRole:  e.ActorRole,
+		Department: e.Department,
+		ReportID:   e.ReportID,
+		ReportIDs:  e.ReportIDs,
+		Action:     e.Action,
+		TraceID:    e.TraceID,
+		Details:    e.Details,
+	}
+	body, _ := json.Marshal(canonical)
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func checkpointMessage(seq int64, hash string, createdAt time.Time) []byte {
+	return []byte(fmt.Sprintf("audit-checkpoint|%d|%s|%s", seq, hash, createdAt.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano)))
+}
+
+// headMessage is what a signed head attests: at createdAt the chain ended at
+// seq with hash.
+func headMessage(seq int64, hash string, createdAt time.Time) []byte {
+	return []byte(fmt.Sprintf("audit-head|%d|%s|%s", seq, hash, createdAt.UTC().Truncate(time.Millisecond).Format(time.RFC3339Nano)))
+}
+
+func signAuditRecord(seq int64, hash string, message func(int64, string, time.Time) []byte) models.AuditCheckpoint {
+	createdAt := time.Now().UTC().Truncate(time.Millisecond)
+	pub := auditSigningKey.Public().(ed25519.PublicKey)
+	return models.AuditCheckpoint{
+		Seq:       seq,
+		Hash:      hash,
+		CreatedAt: createdAt,
+		KeyID:     auditKeyID(pub),
+		Signature: hex.EncodeToString(ed25519.Sign(auditSigningKey, message(seq, hash, createdAt))),
+	}
+}
+
+func loadAuditHead(ctx context.Context) error {
+	var last models.AuditEntry
+	err := db.Collection(auditCollection).FindOne(ctx,
+		bson.M{"seq": bson.M{"$gt": 0}},
+		options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}}),
+	).Decode(&last)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	auditHeadSeq = last.Seq
+	auditHeadHash = last.Hash
+	auditHeadOK = true
+	return nil
+}
+
+// enqueueAuditEntries hands entries to the appender. It only waits while
+// the queue is full, so a slow database pushes back on requests instead of
+// losing entries; entries are dropped only when the caller gives up.
+func enqueueAuditEntries(ctx context.Context, entries []models.AuditEntry) error {
+	for i, entry := range entries {
+		entry.ID = primitive.NewObjectID()
+		entry.Timestamp = entry.Timestamp.UTC().Truncate(time.Millisecond)
+		select {
+		case auditQueue <- entry:
+		case <-ctx.Done():
+			auditDropped.WithLabelValues("queue_full").Add(float64(len(entries) - i))
+			return fmt.Errorf("audit queue full: %w", ctx.Err())
+		}
+	}
+	return nil
+}
+
+// startAuditAppender is the only writer of the chain in this process. It
+// takes whatever is queued, up to auditBatchSize entries, links the batch
+// onto the head and stores it with one ordered insert.
+func startAuditAppender() {
+	batch := make([]models.AuditEntry, 0, auditBatchSize)
+	for entry := range auditQueue {
+		batch = append(batch[:0], entry)
+	drain:
+		for len(batch) < auditBatchSize {
+			select {
+			case e := <-auditQueue:
+				batch = append(batch, e)
+			default:
+				break drain
+			}
+		}
+		appendAuditBatch(batch)
+	}
+}
+
+// appendAuditBatch writes batch to the chain. The unique index on seq turns
+// a concurrent writer in another replica into a duplicate key error; the
+// head is then reloaded and the entries not yet stored are re-linked. That
+// is retried for as long as it happens, since each lost race means the
+// chain moved on. Other errors are retried auditAppendRetries times before
+// the rest of the batch is dropped.
+func appendAuditBatch(batch []models.AuditEntry) {
+	failures := 0
+	for len(batch) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := insertAuditBatch(ctx, batch)
+		if err == nil {
+			auditCommitted(ctx, batch)
+			cancel()
+			return
+		}
+		auditAppendErrors.Inc()
+		auditHeadOK = false
+
+		// The insert is ordered, so entries before the failing one are
+		// stored as linked; keep them and retry only the rest.
+		written, rest, ferr := splitWrittenAuditEntries(ctx, batch)
+		if ferr == nil {
+			auditCommitted(ctx, written)
+			batch = rest
+		}
+		cancel()
+
+		if ferr == nil && mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		failures++
+		if failures >= auditAppendRetries {
+			auditDropped.WithLabelValues("write_failed").Add(float64(len(batch)))
+			log.Printf("[ERROR] Dropped %d audit entries after %d failed writes: %v", len(batch), failures, err)
+			return
+		}
+		log.Printf("[WARN] Failed to append %d audit entries (attempt %d/%d), retrying: %v", len(batch), failures, auditAppendRetries, err)
+		time.Sleep(time.Duration(failures) * time.Second)
+	}
+}
+
+func insertAuditBatch(ctx context.Context, batch []models.AuditEntry) error {
+	if !auditHeadOK {
+		if err := loadAuditHead(ctx); err != nil {
+			return err
+		}
+	}
+
+	seq, prev := auditHeadSeq, auditHeadHash
+	docs := make([]interface{}, len(batch))
+	for i := range batch {
+		seq++
+		batch[i].Seq = seq
+		batch[i].PrevHash = prev
+		batch[i].Hash = computeAuditHash(batch[i])
+		prev = batch[i].Hash
+		docs[i] = batch[i]
+	}
+	if _, err := db.Collection(auditCollection).InsertMany(ctx, docs, options.InsertMany().SetOrdered(true)); err != nil {
+		return err
+	}
+	auditHeadSeq, auditHeadHash = seq, prev
+	return nil
+}
+
+// splitWrittenAuditEntries finds which entries of a failed batch were
+// stored anyway, by the IDs assigned when they were queued.
+func splitWrittenAuditEntries(ctx context.Context, batch []models.AuditEntry) (written, rest []models.AuditEntry, err error) {
+	ids := make([]primitive.ObjectID, len(batch))
+	for i, e := range batch {
+		ids[i] = e.ID
+	}
+	cursor, err := db.Collection(auditCollection).Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, batch, err
+	}
+	var stored []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, batch, err
+	}
+	found := make(map[primitive.ObjectID]bool, len(stored))
+	for _, s := range stored {
+		found[s.ID] = true
+	}
+	for _, e := range batch {
+		if found[e.ID] {
+			written = append(written, e)
+		} else {
+			rest = append(rest, e)
+		}
+	}
+	return written, rest, nil
+}
+
+// auditCommitted counts stored entries and signs a checkpoint at every
+// auditCheckpointInterval seq among them.
+func auditCommitted(ctx context.Context, entries []models.AuditEntry) {
+	auditAppended.Add(float64(len(entries)))
+	for _, e := range entries {
+		if e.Seq%auditCheckpointInterval() != 0 {
+			continue
+		}
+		if err := writeAuditCheckpoint(ctx, e.Seq, e.Hash); err != nil {
+			log.Printf("[WARN] Failed to write audit checkpoint at seq %d: %v", e.Seq, err)
+		}
+	}
+}
+
+func writeAuditCheckpoint(ctx context.Context, seq int64, hash string) error {
+	_, err := db.Collection(auditCheckpointCollection).InsertOne(ctx, signAuditRecord(seq, hash, checkpointMessage))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// signAuditHead attests where the chain ends right now. Heads are signed on
+// a schedule even when nothing changed, so entries deleted from the tail
+// show up as a chain shorter than the latest head, and a verifier can tell
+// from the head's age how much of the tail is not yet vouched for.
+func signAuditHead(ctx context.Context) error {
+	var head models.AuditEntry
+	err := db.Collection(auditCollection).FindOne(ctx,
+		bson.M{"seq": bson.M{"$gt": 0}},
+		options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}}),
+	).Decode(&head)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	_, err = db.Collection(auditHeadCollection).InsertOne(ctx, signAuditRecord(head.Seq, head.Hash, headMessage))
+	return err
+}
+
+func startAuditCheckpointWorker() {
+	every := auditHeadInterval()
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := signAuditHead(ctx); err != nil {
+			log.Printf("[WARN] Failed to sign audit chain head: %v", err)
+		}
+		cancel()
+		<-ticker.C
+	}
+}
+
+type auditBrokenLink struct {
+	Seq    int64  `json:"seq"`
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason"`
+}
+
+type auditVerification struct {
+	Valid              bool             `json:"valid"`
+	EntriesChecked     int64            `json:"entries_checked"`
+	HeadSeq            int64            `json:"head_seq"`
+	HeadHash           string           `json:"head_hash"`
+	CheckpointsChecked int              `json:"checkpoints_checked"`
+	UnchainedEntries   int64            `json:"unchained_entries"`
+	SignedHeadSeq      int64            `json:"signed_head_seq"`
+	SignedHeadAt       *time.Time       `json:"signed_head_at,omitempty"`
+	FirstBroken        *auditBrokenLink `json:"first_broken,omitempty"`
+	PublicKey          string           `json:"public_key"`
+	KeyID              string           `json:"key_id"`
+	VerifiedAt         time.Time        `json:"verified_at"`
+}
+
+// verifyAuditChain loads the checkpoints and signed heads and streams the
+// chain through checkAuditChain.
+func verifyAuditChain(ctx context.Context, pub ed25519.PublicKey) (*auditVerification, error) {
+	unchained, err := db.Collection(auditCollection).CountDocuments(ctx, bson.M{"seq": bson.M{"$exists": false}})
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints, err := loadAuditCheckpoints(ctx, auditCheckpointCollection)
+	if err != nil {
+		return nil, err
+	}
+	heads, err := loadAuditCheckpoints(ctx, auditHeadCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := db.Collection(auditCollection).Find(ctx,
+		bson.M{"seq": bson.M{"$gt": 0}},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	next := func() (*models.AuditEntry, error) {
+		if !cursor.Next(ctx) {
+			return nil, cursor.Err()
+		}
+		var entry models.AuditEntry
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+		return &entry, nil
+	}
+
+	result, err := checkAuditChain(pub, checkpoints, heads, next, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	result.UnchainedEntries = unchained
+	return result, nil
+}
+
+// checkAuditChain checks every checkpoint and signed head against the
+// pinned public key, then walks the entries next yields in seq order and
+// stops at the first whose sequence, back-link, content hash or signed hash
+// does not match. The chain must reach the latest signed head, and that head
+// must be recent, otherwise entries may have been cut from the tail. next
+// returns nil when the entries run out.
+func checkAuditChain(pub ed25519.PublicKey, checkpoints, heads []models.AuditCheckpoint, next func() (*models.AuditEntry, error), now time.Time) (*auditVerification, error) {
+	result := &auditVerification{
+		Valid:              true,
+		CheckpointsChecked: len(checkpoints) + len(heads),
+		PublicKey:          hex.EncodeToString(pub),
+		KeyID:              auditKeyID(pub),
+		VerifiedAt:         now,
+	}
+
+	// signed maps each attested seq to its hash; the highest one is where
+	// the chain must at least reach.
+	signed := make(map[int64]string, len(checkpoints)+len(heads))
+	var signedSeq int64
+	var latestHead *models.AuditCheckpoint
+	for _, set := range []struct {
+		records []models.AuditCheckpoint
+		message func(int64, string, time.Time) []byte
+		name    string
+	}{
+		{checkpoints, checkpointMessage, "checkpoint"},
+		{heads, headMessage, "signed head"},
+	} {
+		for i, cp := range set.records {
+			sig, err := hex.DecodeString(cp.Signature)
+			if err != nil || !ed25519.Verify(pub, set.message(cp.Seq, cp.Hash, cp.CreatedAt), sig) {
+				result.Valid = false
+				result.FirstBroken = &auditBrokenLink{Seq: cp.Seq, ID: cp.ID.Hex(), Reason: set.name + " signature invalid"}
+				return result, nil
+			}
+			if cp.Seq > 0 {
+				if h, ok := signed[cp.Seq]; ok && h != cp.Hash {
+					result.Valid = false
+					result.FirstBroken = &auditBrokenLink{Seq: cp.Seq, ID: cp.ID.Hex(), Reason: "signed hashes disagree"}
+					return result, nil
+				}
+				signed[cp.Seq] = cp.Hash
+			}
+			if cp.Seq > signedSeq {
+				signedSeq = cp.Seq
+			}
+			if set.name == "signed head" && (latestHead == nil || cp.CreatedAt.After(latestHead.CreatedAt)) {
+				latestHead = &set.records[i]
+			}
+		}
+	}
+	result.SignedHeadSeq = signedSeq
+	if latestHead != nil {
+		at := latestHead.CreatedAt
+		result.SignedHeadAt = &at
+	}
+
+	expectedSeq := int64(1)
+	prevHash := ""
+
+	for {
+		entry, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			break
+		}
+		result.EntriesChecked++
+
+		var reason string
+		switch {
+		case entry.Seq != expectedSeq:
+			reason = fmt.Sprintf("sequence gap: expected %d, found %d (entries deleted)", expectedSeq, entry.Seq)
+		case entry.PrevHash != prevHash:
+			reason = "prev_hash does not match the hash of the previous entry"
+		case computeAuditHash(*entry) != entry.Hash:
+			reason = "content hash mismatch (entry altered)"
+		}
+		if h, ok := signed[entry.Seq]; reason == "" && ok && h != entry.Hash {
+			reason = "chain hash differs from signed checkpoint"
+		}
+		if reason != "" {
+			result.Valid = false
+			result.FirstBroken = &auditBrokenLink{Seq: entry.Seq, ID: entry.ID.Hex(), Reason: reason}
+			return result, nil
+		}
+
+		prevHash = entry.Hash
+		result.HeadSeq = entry.Seq
+		result.HeadHash = entry.Hash
+		expectedSeq++
+	}
+
+	switch {
+	case signedSeq > result.HeadSeq:
+		result.Valid = false
+		result.FirstBroken = &auditBrokenLink{
+			Seq:    result.HeadSeq + 1,
+			Reason: fmt.Sprintf("chain ends at %d but a signed record covers %d (entries truncated)", result.HeadSeq, signedSeq),
+		}
+	case result.HeadSeq > 0 && (latestHead == nil || now.Sub(latestHead.CreatedAt) > 2*auditHeadInterval()):
+		// Without a fresh signed head, entries after the last signature
+		// could have been removed without trace.
+		result.Valid = false
+		result.FirstBroken = &auditBrokenLink{
+			Seq:    signedSeq + 1,
+			Reason: "no recent signed head; entries after the last signed record are not vouched for",
+		}
+	}
+
+	return result, nil
+}
+
+func loadAuditCheckpoints(ctx context.Context, collection string) ([]models.AuditCheckpoint, error) {
+	cursor, err := db.Collection(collection).Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	checkpoints := make([]models.AuditCheckpoint, 0)
+	if err := cursor.All(ctx, &checkpoints); err != nil {
+		return nil, err
+	}
+	return checkpoints, nil
+}
+
+func adminAuditVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	pub, err := auditPinnedPublicKey(r.URL.Query().Get("public_key"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "No pinned audit public key", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	result, err := verifyAuditChain(ctx, pub)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to verify audit chain", err.Error())
+		return
+	}
+
+	message := "Audit chain verified"
+	if !result.Valid {
+		message = "Audit chain is broken"
+	}
+	response.Success(w, http.StatusOK, message, result)
+}
+
+// runVerifyAuditCommand backs `report-service verify-audit [public-key]`; it
+// verifies against the given key or AUDIT_PUBLIC_KEY, prints the result as
+// JSON and exits non-zero when the chain is broken.
+func runVerifyAuditCommand(args []string) int {
+	given := ""
+	if len(args) > 0 {
+		given = args[0]
+	}
+	pub, err := auditPinnedPublicKey(given)
+	if err != nil {
+		log.Printf("[ERROR] Audit verification failed: %v", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	result, err := verifyAuditChain(ctx, pub)
+	if err != nil {
+		log.Printf("[ERROR] Audit verification failed: %v", err)
+		return 2
+	}
+
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+	if !result.Valid {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"citizen-reporting-system/services/report-service/models"
+)
+
+func testAuditKey(t *testing.T) ed25519.PublicKey {
+	t.Helper()
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i + 1)
+	}
+	auditSigningKey = ed25519.NewKeyFromSeed(seed)
+	return auditSigningKey.Public().(ed25519.PublicKey)
+}
+
+// testAuditChain builds n correctly linked entries.
+func testAuditChain(n int) []models.AuditEntry {
+	base := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	entries := make([]models.AuditEntry, 0, n)
+	prev := ""
+	for i := 1; i <= n; i++ {
+		e := models.AuditEntry{
+			Seq:        int64(i),
+			PrevHash:   prev,
+			Timestamp:  base.Add(time.Duration(i) * time.Minute),
+			ActorID:    "admin-1",
+			ActorRole:  "admin",
+			Department: "kebersihan",
+			ReportID:   fmt.Sprintf("report-%d", i),
+			Action:     models.AuditActionStatusChange,
+			Details:    map[string]string{"status": "IN_PROGRESS"},
+		}
+		e.Hash = computeAuditHash(e)
+		prev = e.Hash
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// rehashFrom recomputes links and hashes from index i on, as an attacker
+// with write access to the collection would.
+func rehashFrom(entries []models.AuditEntry, i int) {
+	for ; i < len(entries); i++ {
+		if i > 0 {
+			entries[i].PrevHash = entries[i-1].Hash
+		}
+		entries[i].Hash = computeAuditHash(entries[i])
+	}
+}
+
+func auditEntryIter(entries []models.AuditEntry) func() (*models.AuditEntry, error) {
+	i := 0
+	return func() (*models.AuditEntry, error) {
+		if i >= len(entries) {
+			return nil, nil
+		}
+		e := entries[i]
+		i++
+		return &e, nil
+	}
+}
+
+func TestComputeAuditHash(t *testing.T) {
+	base := testAuditChain(1)[0]
+	base.Details = nil
+	want := computeAuditHash(base)
+
+	tests := []struct {
+		name   string
+		mutate func(e *models.AuditEntry)
+		same   bool
+	}{
+		{"unchanged", func(e *models.AuditEntry) {}, true},
+		{"sub-millisecond timestamp lost in BSON", func(e *models.AuditEntry) { e.Timestamp = e.Timestamp.Add(300 * time.Microsecond) }, true},
+		{"timestamp in another zone", func(e *models.AuditEntry) { e.Timestamp = e.Timestamp.In(time.FixedZone("WIB", 7*3600)) }, true},
+		{"empty details equal missing details", func(e *models.AuditEntry) { e.Details = map[string]string{} }, true},
+		{"stored hash is not hashed", func(e *models.AuditEntry) { e.Hash = "x" }, true},
+		{"actor changed", func(e *models.AuditEntry) { e.ActorID = "admin-2" }, false},
+		{"report changed", func(e *models.AuditEntry) { e.ReportID = "other" }, false},
+		{"report list added", func(e *models.AuditEntry) { e.ReportIDs = []string{"a", "b"} }, false},
+		{"detail added", func(e *models.AuditEntry) { e.Details = map[string]string{"status": "RESOLVED"} }, false},
+		{"back-link changed", func(e *models.AuditEntry) { e.PrevHash = "00" }, false},
+		{"seq changed", func(e *models.AuditEntry) { e.Seq = 2 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := base
+			tt.mutate(&e)
+			if same := computeAuditHash(e) == want; same != tt.same {
+				t.Errorf("hash unchanged = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func TestCheckAuditChain(t *testing.T) {
+	pub := testAuditKey(t)
+	otherSeed := make([]byte, ed25519.SeedSize)
+	otherKey := ed25519.NewKeyFromSeed(otherSeed)
+
+	tests := []struct {
+		name string
+		// setup returns the entries, checkpoints and heads to verify, and
+		// how far past now verification runs.
+		setup      func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration)
+		wantValid  bool
+		wantSeq    int64
+		wantReason string
+	}{
+		{
+			name: "intact chain with fresh head",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				c := testAuditChain(5)
+				return c, []models.AuditCheckpoint{signAuditRecord(3, c[2].Hash, checkpointMessage)},
+					[]models.AuditCheckpoint{signAuditRecord(5, c[4].Hash, headMessage)}, 0
+			},
+			wantValid: true,
+		},
+		{
+			name: "empty log",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				return nil, nil, nil, 0
+			},
+			wantValid: true,
+		},
+		{
+			name: "entry edited in place",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				c := testAuditChain(5)
+				c[2].Details = map[string]string{"status": "RESOLVED"}
+				return c, nil, []models.AuditCheckpoint{signAuditRecord(5, c[4].Hash, headMessage)}, 0
+			},
+			wantSeq:    3,
+			wantReason: "content hash mismatch",
+		},
+		{
+			name: "entry edited and rehashed",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				c := testAuditChain(5)
+				head := signAuditRecord(5, c[4].Hash, headMessage)
+				c[2].ActorID = "someone-else"
+				c[2].Hash = computeAuditHash(c[2])
+				return c, nil, []models.AuditCheckpoint{head}, 0
+			},
+			wantSeq:    4,
+			wantReason: "prev_hash does not match",
+		},
+		{
+			name: "tail rewritten consistently past a checkpoint",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				c := testAuditChain(5)
+				cp := signAuditRecord(4, c[3].Hash, checkpointMessage)
+				c[2].ActorID = "someone-else"
+				rehashFrom(c, 2)
+				return c, []models.AuditCheckpoint{cp}, []models.AuditCheckpoint{signAuditRecord(5, c[4].Hash, headMessage)}, 0
+			},
+			wantSeq:    4,
+			wantReason: "differs from signed checkpoint",
+		},
+		{
+			name: "entry deleted from the middle",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				c := testAuditChain(5)
+				head := signAuditRecord(5, c[4].Hash, headMessage)
+				return append(c[:2:2], c[3:]...), nil, []models.AuditCheckpoint{head}, 0
+			},
+			wantSeq:    4,
+			wantReason: "sequence gap",
+		},
+		{
+			name: "tail truncated below the signed head",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				c := testAuditChain(5)
+				return c[:3], nil, []models.AuditCheckpoint{signAuditRecord(5, c[4].Hash, headMessage)}, 0
+			},
+			wantSeq:    4,
+			wantReason: "entries truncated",
+		},
+		{
+			name: "no signed head at all",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				c := testAuditChain(5)
+				return c, []models.AuditCheckpoint{signAuditRecord(3, c[2].Hash, checkpointMessage)}, nil, 0
+			},
+			wantSeq:    4,
+			wantReason: "no recent signed head",
+		},
+		{
+			name: "signed head has gone stale",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				c := testAuditChain(5)
+				return c, nil, []models.AuditCheckpoint{signAuditRecord(5, c[4].Hash, headMessage)}, 3 * auditHeadInterval()
+			},
+			wantSeq:    6,
+			wantReason: "no recent signed head",
+		},
+		{
+			name: "checkpoint signed by another key",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				c := testAuditChain(5)
+				pinned := auditSigningKey
+				auditSigningKey = otherKey
+				forged := signAuditRecord(3, c[2].Hash, checkpointMessage)
+				auditSigningKey = pinned
+				return c, []models.AuditCheckpoint{forged}, []models.AuditCheckpoint{signAuditRecord(5, c[4].Hash, headMessage)}, 0
+			},
+			wantSeq:    3,
+			wantReason: "checkpoint signature invalid",
+		},
+		{
+			name: "head signature replayed onto another seq",
+			setup: func() ([]models.AuditEntry, []models.AuditCheckpoint, []models.AuditCheckpoint, time.Duration) {
+				c := testAuditChain(5)
+				head := signAuditRecord(5, c[4].Hash, headMessage)
+				head.Seq = 3
+				return c[:3], nil, []models.AuditCheckpoint{head}, 0
+			},
+			wantSeq:    3,
+			wantReason: "signed head signature invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, checkpoints, heads, later := tt.setup()
+			got, err := checkAuditChain(pub, checkpoints, heads, auditEntryIter(entries), time.Now().Add(later))
+			if err != nil {
+				t.Fatalf("checkAuditChain: %v", err)
+			}
+			if got.Valid != tt.wantValid {
+				t.Fatalf("Valid = %v, want %v (broken: %+v)", got.Valid, tt.wantValid, got.FirstBroken)
+			}
+			if tt.wantValid {
+				if got.FirstBroken != nil {
+					t.Errorf("FirstBroken = %+v, want nil", got.FirstBroken)
+				}
+				if got.HeadSeq != int64(len(entries)) {
+					t.Errorf("HeadSeq = %d, want %d", got.HeadSeq, len(entries))
+				}
+				return
+			}
+			if got.FirstBroken == nil {
+				t.Fatal("FirstBroken is nil for an invalid chain")
+			}
+			if got.FirstBroken.Seq != tt.wantSeq {
+				t.Errorf("FirstBroken.Seq = %d, want %d", got.FirstBroken.Seq, tt.wantSeq)
+			}
+			if !strings.Contains(got.FirstBroken.Reason, tt.wantReason) {
+				t.Errorf("FirstBroken.Reason = %q, want it to contain %q", got.FirstBroken.Reason, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/services/report-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var knownDepartments = []string{"kebersihan", "pekerjaan_umum", "penerangan_jalan", "lingkungan_hidup", "perhubungan", "keamanan", "general"}
+
+// departmentKey maps any known alias ("DINAS PU", "pekerjaan_umum", ...) to
+// one canonical key so the same department is not assigned twice.
+func departmentKey(name string) string {
+	for _, key := range knownDepartments {
+		for _, alias := range departmentAliases(key) {
+			if strings.EqualFold(alias, strings.TrimSpace(name)) {
+				return key
+			}
+		}
+	}
+	return normalizeDepartment(name)
+}
+
+// assignDepartment adds department to the report's assigned departments
+// unless it is there already under any alias. The check is part of the
+// update, so concurrent dispatches cannot both add it. Reports created
+// without departments store null, hence the pipeline rather than $push.
+func assignDepartment(ctx context.Context, reportID primitive.ObjectID, department string) error {
+	names := departmentAliases(departmentKey(department))
+	if !containsString(names, department) {
+		names = append(names, department)
+	}
+	_, err := db.Collection("reports").UpdateOne(ctx,
+		bson.M{"_id": reportID, "assigned_departments": bson.M{"$nin": names}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"assigned_departments": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$assigned_departments", bson.A{}}},
+				bson.A{department},
+			}},
+		}}}},
+	)
+	return err
+}
+
+// markDispatched moves a PENDING report to DISPATCHED and notifies the
+// citizen. A report past PENDING is left alone.
+func markDispatched(ctx context.Context, reportID primitive.ObjectID, department string, at time.Time) (bool, error) {
+	_, err := updateReportAndNotifyTx(ctx,
+		bson.M{"_id": reportID, "status": "PENDING"},
+		bson.M{"$set": bson.M{"status": "DISPATCHED", "dispatched_at": at, "updated_at": time.Now()}},
+		"Laporan Diteruskan ke "+department, "DISPATCHED")
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// internalReportDispatchedHandler records the dispatcher's routing outcome:
+// the connector receipt is stored on the report and the department is
+// assigned. A receipt repeating an earlier one (same department and
+// reference) is ignored. Only a DELIVERED receipt moves a PENDING report to
+// DISPATCHED with a citizen notification; a QUEUED one waits for staff to
+// complete the manual dispatch.
+func internalReportDispatchedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	var input struct {
+		ReportID string                 `json:"report_id"`
+		Receipt  models.DispatchReceipt `json:"receipt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if input.ReportID == "" || input.Receipt.Department == "" || input.Receipt.Connector == "" {
+		response.Error(w, http.StatusBadRequest, "report_id, department and connector are required", "")
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(input.ReportID)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid report ID", err.Error())
+		return
+	}
+
+	now := time.Now()
+	receipt := input.Receipt
+	receipt.RecordedAt = now
+	receipt.HandledBy = ""
+	receipt.HandledAt = nil
+	if receipt.DeliveredAt.IsZero() {
+		receipt.DeliveredAt = now
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	duplicate, statusChanged := false, false
+	err = runInTransaction(ctx, func(ctx context.Context) error {
+		duplicate, statusChanged = false, false
+
+		res, err := db.Collection("reports").UpdateOne(ctx,
+			bson.M{"_id": objID, "dispatch_receipts": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+				"department": receipt.Department,
+				"reference":  receiptReferenceFilter(receipt.Reference),
+			}}}},
+			bson.M{"$push": bson.M{"dispatch_receipts": receipt}, "$set": bson.M{"updated_at": now}},
+		)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			if err := db.Collection("reports").FindOne(ctx, bson.M{"_id": objID}).Err(); err != nil {
+				return err
+			}
+			duplicate = true
+			return nil
+		}
+
+		if err := assignDepartment(ctx, objID, receipt.Department); err != nil {
+			return err
+		}
+		if receipt.Status != models.ReceiptDelivered {
+			return nil
+		}
+		statusChanged, err = markDispatched(ctx, objID, receipt.Department, receipt.DeliveredAt)
+		return err
+	})
+	if err == mongo.ErrNoDocuments {
+		response.Error(w, http.StatusNotFound, "Report not found", "")
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to record dispatch", err.Error())
+		return
+	}
+	if duplicate {
+		response.Success(w, http.StatusOK, "Dispatch already recorded", map[string]interface{}{
+			"status_changed": false,
+		})
+		return
+	}
+
+	details := map[string]string{
+		"department": receipt.Department,
+		"connector":  receipt.Connector,
+		"status":     receipt.Status,
+		"reference":  receipt.Reference,
+	}
+	if statusChanged {
+		details["report_status"] = "DISPATCHED"
+	}
+	writeAudit(middleware.GetTraceID(r), "service:"+middleware.GetCallerService(r), "service", "", models.AuditActionDispatch, []string{input.ReportID}, details)
+
+	response.Success(w, http.StatusOK, "Dispatch recorded", map[string]interface{}{
+		"status_changed": statusChanged,
+	})
+}
+
+// receiptReferenceFilter matches a stored receipt reference; an empty one
+// also matches receipts stored without the field.
+func receiptReferenceFilter(reference string) interface{} {
+	if reference == "" {
+		return bson.M{"$in": []interface{}{"", nil}}
+	}
+	return reference
+}
+
+func manualDispatchFilter(r *http.Request) bson.M {
+	match := bson.M{"connector": "manual", "status": models.ReceiptQueued}
+
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims)
+	if ok && claims.Role != "super-admin" && claims.Department != "" && claims.Department != "general" {
+		match["department"] = bson.M{"$in": departmentAliases(claims.Department)}
+	}
+	return bson.M{"dispatch_receipts": bson.M{"$elemMatch": match}}
+}
+
+// adminManualDispatchHandler serves the dashboard's manual dispatch list:
+//
+//	GET  /api/reports/admin/manual-dispatch                  queued reports
+//	POST /api/reports/admin/manual-dispatch/{id}/complete    mark handled
+func adminManualDispatchHandler(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/reports/admin/manual-dispatch"), "/")
+
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+			return
+		}
+		listManualDispatch(w, r)
+		return
+	}
+
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || parts[1] != "complete" || r.Method != http.MethodPost {
+		response.Error(w, http.StatusNotFound, "Not found", "")
+		return
+	}
+	completeManualDispatch(w, r, parts[0])
+}
+
+func listManualDispatch(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection("reports").Find(ctx, manualDispatchFilter(r),
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(200))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch manual dispatch queue", err.Error())
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var reports []models.Report
+	if err := cursor.All(ctx, &reports); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to decode reports", err.Error())
+		return
+	}
+	reports = decryptReports(reports)
+	auditReadReports(r, reports)
+
+	response.Success(w, http.StatusOK, "Manual dispatch queue fetched successfully", reports)
+}
+
+func completeManualDispatch(w http.ResponseWriter, r *http.Request, id string) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid report ID", err.Error())
+		return
+	}
+
+	var input struct {
+		Reference string `json:"reference"`
+		Notes     string `json:"notes"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&input)
+
+	actorID, _, _ := auditActor(r)
+	// Mongo keeps milliseconds; truncating lets the receipt be found by time.
+	now := time.Now().Truncate(time.Millisecond)
+
+	filter := manualDispatchFilter(r)
+	filter["_id"] = objID
+	match := filter["dispatch_receipts"].(bson.M)["$elemMatch"].(bson.M)
+
+	arrayFilter := bson.M{"r.connector": "manual", "r.status": models.ReceiptQueued}
+	if dept, ok := match["department"]; ok {
+		arrayFilter["r.department"] = dept
+	}
+
+	set := bson.M{
+		"dispatch_receipts.$[r].status":     models.ReceiptHandled,
+		"dispatch_receipts.$[r].handled_by": actorID,
+		"dispatch_receipts.$[r].handled_at": now,
+		"updated_at":                        now,
+	}
+	if input.Reference != "" {
+		set["dispatch_receipts.$[r].reference"] = input.Reference
+	}
+	if input.Notes != "" {
+		set["dispatch_receipts.$[r].detail"] = input.Notes
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	// Staff handing the report over is what dispatches it, so a PENDING
+	// report moves to DISPATCHED now rather than when it was queued.
+	err = runInTransaction(ctx, func(ctx context.Context) error {
+		var report models.Report
+		err := db.Collection("reports").FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+			options.FindOneAndUpdate().
+				SetArrayFilters(options.ArrayFilters{Filters: []interface{}{arrayFilter}}).
+				SetReturnDocument(options.After),
+		).Decode(&report)
+		if err != nil {
+			return err
+		}
+		department := ""
+		for _, receipt := range report.DispatchReceipts {
+			if receipt.Connector == "manual" && receipt.HandledBy == actorID && receipt.HandledAt != nil && receipt.HandledAt.Equal(now) {
+				department = receipt.Department
+				break
+			}
+		}
+		_, err = markDispatched(ctx, objID, department, now)
+		return err
+	})
+	if err == mongo.ErrNoDocuments {
+		response.Error(w, http.StatusNotFound, "No queued manual dispatch for this report", "")
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to update manual dispatch", err.Error())
+		return
+	}
+
+	recordAudit(r, models.AuditActionDispatch, []string{id}, map[string]string{
+		"connector": "manual",
+		"status":    models.ReceiptHandled,
+		"reference": input.Reference,
+	})
+
+	response.Success(w, http.StatusOK, "Manual dispatch marked as handled", nil)
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/events"
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/services/report-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	followersCollection = "report_followers"
+	followedUpdateType  = "followed_update"
+)
+
+// followerFanoutBatch caps the followers addressed by one notification
+// event; larger audiences are split over several events.
+var followerFanoutBatch = envIntOr("FOLLOWER_FANOUT_BATCH", 500)
+
+func ensureFollowerIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(followersCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "report_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+// followReport subscribes the user to the report. An existing follow keeps
+// its original source, so upvoting does not turn a manual follow into one
+// that is dropped with the upvote.
+func followReport(ctx context.Context, reportID primitive.ObjectID, userID, source string) error {
+	_, err := db.Collection(followersCollection).UpdateOne(ctx,
+		bson.M{"report_id": reportID, "user_id": userID},
+		bson.M{"$setOnInsert": models.ReportFollower{
+			ReportID:  reportID,
+			UserID:    userID,
+			Source:    source,
+			CreatedAt: time.Now(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// unfollowReport removes the user's follow; a non-empty source only removes
+// a follow made that way.
+func unfollowReport(ctx context.Context, reportID primitive.ObjectID, userID, source string) error {
+	filter := bson.M{"report_id": reportID, "user_id": userID}
+	if source != "" {
+		filter["source"] = source
+	}
+	_, err := db.Collection(followersCollection).DeleteOne(ctx, filter)
+	return err
+}
+
+// markFollowing sets IsFollowing on the reports the user follows.
+func markFollowing(ctx context.Context, reports []models.Report, userID string) {
+	if userID == "" || len(reports) == 0 {
+		return
+	}
+	ids := make([]primitive.ObjectID, 0, len(reports))
+	for _, report := range reports {
+		ids = append(ids, report.ID)
+	}
+	cursor, err := db.Collection(followersCollection).Find(ctx,
+		bson.M{"user_id": userID, "report_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"report_id": 1}),
+	)
+	if err != nil {
+		log.Printf("[WARN] Failed to look up followed reports: %v", err)
+		return
+	}
+	var follows []models.ReportFollower
+	if err := cursor.All(ctx, &follows); err != nil {
+		log.Printf("[WARN] Failed to decode followed reports: %v", err)
+		return
+	}
+	followed := make(map[primitive.ObjectID]bool, len(follows))
+	for _, f := range follows {
+		followed[f.ReportID] = true
+	}
+	for i := range reports {
+		reports[i].IsFollowing = followed[reports[i].ID]
+	}
+}
+
+// reportFollowHandler handles POST and DELETE /api/reports/{id}/follow.
+func reportFollowHandler(w http.ResponseWriter, r *http.Request, id string) {
+	claims, _ := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims)
+	if claims == nil {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid report ID", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	switch r.Method {
+	case http.MethodPost:
+		var report models.Report
+		if err := db.Collection("reports").FindOne(ctx, bson.M{"_id": objID}).Decode(&report); err != nil {
+			if err == mongo.ErrNoDocuments {
+				response.Error(w, http.StatusNotFound, "Report not found", "")
+				return
+			}
+			response.Error(w, http.StatusInternalServerError, "Failed to fetch report", err.Error())
+			return
+		}
+		if !report.IsPublic {
+			response.Error(w, http.StatusForbidden, "Forbidden", "Cannot follow private reports")
+			return
+		}
+		if err := followReport(ctx, objID, claims.UserID, models.FollowSourceManual); err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to follow report", err.Error())
+			return
+		}
+		response.Success(w, http.StatusOK, "Following report", map[string]interface{}{"is_following": true})
+
+	case http.MethodDelete:
+		if err := unfollowReport(ctx, objID, claims.UserID, ""); err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to unfollow report", err.Error())
+			return
+		}
+		response.Success(w, http.StatusOK, "Unfollowed report", map[string]interface{}{"is_following": false})
+
+	default:
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+	}
+}
+
+// followingReportsHandler lists the public reports the caller follows, most
+// recently followed first.
+func followingReportsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+	claims, ok := r.Context().Value(middleware.UserContextKey).(*middleware.UserClaims)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized", "")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection(followersCollection).Find(ctx,
+		bson.M{"user_id": claims.UserID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch followed reports", err.Error())
+		return
+	}
+	var follows []models.ReportFollower
+	if err := cursor.All(ctx, &follows); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to decode followed reports", err.Error())
+		return
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(follows))
+	for _, f := range follows {
+		ids = append(ids, f.ReportID)
+	}
+	reports := []models.Report{}
+	if len(ids) > 0 {
+		cursor, err := db.Collection("reports").Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "is_public": true})
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to fetch reports", err.Error())
+			return
+		}
+		var found []models.Report
+		if err := cursor.All(ctx, &found); err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to decode reports", err.Error())
+			return
+		}
+		byID := make(map[primitive.ObjectID]models.Report, len(found))
+		for _, report := range found {
+			byID[report.ID] = report
+		}
+		for _, id := range ids {
+			if report, ok := byID[id]; ok {
+				report.IsFollowing = true
+				reports = append(reports, report)
+			}
+		}
+	}
+
+	reports = maskAnonymousReporter(reports)
+	reports = decryptReports(reports)
+	auditReadReports(r, reports)
+	for i := range reports {
+		computeHasUpvoted(&reports[i], claims.UserID)
+	}
+	response.Success(w, http.StatusOK, "Followed reports fetched successfully", reports)
+}
+
+// followerNotificationEvents builds the status notifications for everyone
+// following a public report other than its reporter, at most
+// followerFanoutBatch users per event.
+func followerNotificationEvents(ctx context.Context, report models.Report, status string) ([]models.OutboxEvent, error) {
+	if !report.IsPublic {
+		return nil, nil
+	}
+
+	cursor, err := db.Collection(followersCollection).Find(ctx,
+		bson.M{"report_id": report.ID},
+		options.Find().SetProjection(bson.M{"user_id": 1}).SetSort(bson.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reporter := notificationUserID(report)
+	status = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(status), "-", "_"))
+	title := "Laporan yang Anda Ikuti Diperbarui"
+	message := fmt.Sprintf("Status laporan \"%s\" berubah menjadi: %s", report.Title, translateStatus(status))
+	if status == "RESOLVED" {
+		title = "Laporan yang Anda Ikuti Telah Selesai"
+		message = fmt.Sprintf("Laporan \"%s\" telah diselesaikan", report.Title)
+	}
+
+	var out []models.OutboxEvent
+	var batch []string
+	flush := func() error {
+		reportID := report.ID.Hex()
+		event, err := newOutboxEvent(events.TypeReportUpdated, reportID, "reports", "report.updated", events.Notification{
+			ID:        reportID,
+			ReportID:  reportID,
+			Title:     title,
+			Message:   message,
+			Type:      followedUpdateType,
+			Status:    status,
+			Category:  report.Category,
+			UserIDs:   batch,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		out = append(out, event)
+		batch = nil
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var f models.ReportFollower
+		if err := cursor.Decode(&f); err != nil {
+			return nil, err
+		}
+		if f.UserID == "" || f.UserID == reporter {
+			continue
+		}
+		batch = append(batch, f.UserID)
+		if len(batch) >= followerFanoutBatch {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,734 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"citizen-reporting-system/pkg/middleware"
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/pkg/telemetry"
+	"citizen-reporting-system/services/report-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	forwardCollection         = "forwarded_reports"
+	forwardCallbackCollection = "forward_callbacks"
+	forwardLockDuration       = time.Minute
+	forwardCallbackMaxSkew    = 5 * time.Minute
+)
+
+type forwardConfig struct {
+	URL              string
+	Timeout          time.Duration
+	MaxAttempts      int
+	RetryBase        time.Duration
+	RetryMax         time.Duration
+	PollInterval     time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+func envDurationOr(key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
+func envIntOr(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+func loadForwardConfig() forwardConfig {
+	url := strings.TrimSpace(os.Getenv("FORWARD_EXTERNAL_URL"))
+	if url == "" {
+		url = "http://dispatcher-service:8085/external/forward"
+	}
+	return forwardConfig{
+		URL:              url,
+		Timeout:          envDurationOr("FORWARD_TIMEOUT", 10*time.Second),
+		MaxAttempts:      envIntOr("FORWARD_MAX_ATTEMPTS", 8),
+		RetryBase:        envDurationOr("FORWARD_RETRY_BASE", 5*time.Second),
+		RetryMax:         envDurationOr("FORWARD_RETRY_MAX", 10*time.Minute),
+		PollInterval:     envDurationOr("FORWARD_POLL_INTERVAL", 2*time.Second),
+		BreakerThreshold: envIntOr("FORWARD_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:  envDurationOr("FORWARD_BREAKER_COOLDOWN", time.Minute),
+	}
+}
+
+func (c forwardConfig) backoff(attempts int) time.Duration {
+	d := c.RetryBase
+	for i := 1; i < attempts && d < c.RetryMax; i++ {
+		d *= 2
+	}
+	if d > c.RetryMax {
+		d = c.RetryMax
+	}
+	return d
+}
+
+// circuitBreaker stops the forward worker from hammering an external system
+// that keeps failing. After threshold consecutive failures it opens for the
+// cooldown, then lets a single trial request through (half-open).
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.trial {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// Release gives back a trial slot that was not used for a request.
+func (b *circuitBreaker) Release() {
+	b.mu.Lock()
+	b.trial = false
+	b.mu.Unlock()
+}
+
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	b.failures = 0
+	b.trial = false
+	b.mu.Unlock()
+}
+
+func (b *circuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold {
+			log.Printf("[WARN] Forwarding circuit opened after %d consecutive failures", b.failures)
+		}
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+func (b *circuitBreaker) State() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := "closed"
+	if b.failures >= b.threshold {
+		state = "open"
+		if !time.Now().Before(b.openUntil) {
+			state = "half-open"
+		}
+	}
+	out := map[string]interface{}{
+		"state":                state,
+		"consecutive_failures": b.failures,
+	}
+	if state == "open" {
+		out["retry_at"] = b.openUntil.Format(time.RFC3339)
+	}
+	return out
+}
+
+var (
+	forwardCfg     = loadForwardConfig()
+	forwardBreaker = &circuitBreaker{threshold: forwardCfg.BreakerThreshold, cooldown: forwardCfg.BreakerCooldown}
+)
+
+func ensureForwardIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(forwardCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "report_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "external_ticket_id", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"external_ticket_id": bson.M{"$type": "string"}}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(forwardCallbackCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "event_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "received_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(int32((30 * 24 * time.Hour).Seconds()))},
+	})
+	return err
+}
+
+func timelineEntry(source, event, status, note, ref string) models.TimelineEntry {
+	return models.TimelineEntry{At: time.Now(), Source: source, Event: event, Status: status, Note: note, Ref: ref}
+}
+
+// adminForwardReportHandler queues a forward to the external system and
+// returns immediately; the forward worker delivers it with retries.
+func adminForwardReportHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[len("/api/reports/admin/reports/forward/"):]
+	if id == "" {
+		response.Error(w, http.StatusBadRequest, "Missing report ID", "")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	var input struct {
+		ForwardTo string `json:"forwardTo"`
+		Notes     string `json:"notes"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+
+	if input.ForwardTo == "" {
+		response.Error(w, http.StatusBadRequest, "forwardTo is required", "")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid report ID", err.Error())
+		return
+	}
+
+	count, err := db.Collection("reports").CountDocuments(ctx, bson.M{"_id": objID})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch report", err.Error())
+		return
+	}
+	if count == 0 {
+		response.Error(w, http.StatusNotFound, "Report not found", "")
+		return
+	}
+
+	actorID, _, department := auditActor(r)
+	if strings.TrimSpace(department) == "" {
+		department = "general"
+	}
+
+	now := time.Now()
+	job := models.ForwardJob{
+		ID:            primitive.NewObjectID(),
+		ReportID:      objID,
+		ForwardTo:     input.ForwardTo,
+		ForwardedBy:   department,
+		RequestedBy:   actorID,
+		Notes:         input.Notes,
+		ForwardedAt:   now,
+		Status:        models.ForwardStatusQueued,
+		ExternalURL:   forwardCfg.URL,
+		NextAttemptAt: now,
+		TraceID:       middleware.GetTraceID(r),
+		TraceContext:  map[string]string{},
+	}
+	telemetry.Inject(ctx, job.TraceContext)
+
+	err = runInTransaction(ctx, func(ctx context.Context) error {
+		if _, err := db.Collection(forwardCollection).InsertOne(ctx, job); err != nil {
+			return err
+		}
+		_, err := db.Collection("reports").UpdateOne(ctx, bson.M{"_id": objID}, bson.M{
+			"$push": bson.M{"timeline": timelineEntry("admin", "forward_queued", "", "Diteruskan ke "+input.ForwardTo, job.ID.Hex())},
+		})
+		return err
+	})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to forward report", err.Error())
+		return
+	}
+
+	recordAudit(r, models.AuditActionForward, []string{id}, forwardAuditDetails(job, "QUEUED", ""))
+	log.Printf("[OK] Admin queued forward - ID: %s, ForwardTo: %s, Job: %s", id, input.ForwardTo, job.ID.Hex())
+
+	response.Success(w, http.StatusAccepted, "Report forward queued", map[string]interface{}{
+		"report_id":    id,
+		"job_id":       job.ID.Hex(),
+		"forward_to":   input.ForwardTo,
+		"forwarded_at": now,
+		"status":       job.Status,
+		"external_url": forwardCfg.URL,
+	})
+}
+
+func forwardAuditDetails(job models.ForwardJob, outcome, reason string) map[string]string {
+	details := map[string]string{
+		"forward_to":        job.ForwardTo,
+		"forward_record_id": job.ID.Hex(),
+		"forwarded_by":      job.ForwardedBy,
+		"forwarded_at":      job.ForwardedAt.UTC().Format(time.RFC3339Nano),
+		"notes_sha256":      fmt.Sprintf("%x", sha256.Sum256([]byte(job.Notes))),
+		"outcome":           outcome,
+	}
+	if reason != "" {
+		details["reason"] = reason
+	}
+	if job.ExternalTicketID != "" {
+		details["external_ticket_id"] = job.ExternalTicketID
+	}
+	return details
+}
+
+func startForwardWorker() {
+	ticker := time.NewTicker(forwardCfg.PollInterval)
+	defer ticker.Stop()
+
+	log.Println("[INFO] Forward worker started")
+
+	for range ticker.C {
+		for i := 0; i < 20; i++ {
+			if !forwardBreaker.Allow() {
+				break
+			}
+			if !processNextForward() {
+				break
+			}
+		}
+	}
+}
+
+// processNextForward claims one due job with a lease and attempts delivery.
+// It returns false when there was nothing to do.
+func processNextForward() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), forwardCfg.Timeout+15*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var job models.ForwardJob
+	err := db.Collection(forwardCollection).FindOneAndUpdate(ctx,
+		bson.M{
+			"status":          bson.M{"$in": []string{models.ForwardStatusQueued, models.ForwardStatusSending, "PENDING"}},
+			"next_attempt_at": bson.M{"$lte": now},
+			"$or": []bson.M{
+				{"locked_until": bson.M{"$exists": false}},
+				{"locked_until": bson.M{"$lt": now}},
+			},
+		},
+		bson.M{"$set": bson.M{
+			"status":       models.ForwardStatusSending,
+			"locked_until": now.Add(forwardLockDuration),
+			"locked_by":    outboxInstanceID,
+			"attempted_at": now,
+		}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&job)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("[ERROR] Forward worker: failed to claim job: %v", err)
+		}
+		forwardBreaker.Release()
+		return false
+	}
+
+	ctx, span := telemetry.Tracer().Start(telemetry.Extract(ctx, job.TraceContext), "forward.deliver",
+		trace.WithAttributes(
+			attribute.String("forward.id", job.ID.Hex()),
+			attribute.String("report.id", job.ReportID.Hex()),
+			attribute.Int("forward.attempt", job.Attempts+1),
+		),
+	)
+	defer span.End()
+
+	ticketID, statusCode, body, sendErr := sendForward(ctx, job)
+	telemetry.RecordError(span, sendErr)
+	if sendErr != nil {
+		failForward(ctx, job, statusCode, body, sendErr)
+		return true
+	}
+
+	forwardBreaker.Success()
+	completeForward(ctx, job, ticketID, statusCode, body)
+	return true
+}
+
+type permanentForwardError struct{ error }
+
+func sendForward(ctx context.Context, job models.ForwardJob) (string, int, string, error) {
+	var report models.Report
+	if err := db.Collection("reports").FindOne(ctx, bson.M{"_id": job.ReportID}).Decode(&report); err != nil {
+		return "", 0, "", permanentForwardError{fmt.Errorf("report not found: %w", err)}
+	}
+	decryptReport(&report)
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"forwardId":   job.ID.Hex(),
+		"forwardTo":   job.ForwardTo,
+		"notes":       job.Notes,
+		"forwardedBy": job.ForwardedBy,
+		"forwardedAt": job.ForwardedAt,
+		"callbackUrl": os.Getenv("FORWARD_CALLBACK_URL"),
+		"report": map[string]interface{}{
+			"id":            report.ID.Hex(),
+			"title":         report.Title,
+			"description":   report.Description,
+			"location":      report.Location,
+			"category":      report.Category,
+			"is_anonymous":  report.IsAnonymous,
+			"reporter_id":   report.ReporterID,
+			"reporter_name": report.Reporter,
+			"created_at":    report.CreatedAt,
+		},
+	})
+	if err != nil {
+		return "", 0, "", permanentForwardError{err}
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, forwardCfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, job.ExternalURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", 0, "", permanentForwardError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", job.ID.Hex())
+	if err := middleware.SignInternalRequest(req, "dispatcher-service"); err != nil {
+		return "", 0, "", permanentForwardError{err}
+	}
+	if job.TraceID != "" {
+		req.Header.Set("X-Trace-Id", job.TraceID)
+	}
+
+	resp, err := telemetry.HTTPClient.Do(req)
+	if err != nil {
+		return "", 0, "", err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	body := strings.TrimSpace(string(bodyBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("external status %d", resp.StatusCode)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return "", resp.StatusCode, body, permanentForwardError{err}
+		}
+		return "", resp.StatusCode, body, err
+	}
+
+	var ack struct {
+		TicketID  string `json:"ticket_id"`
+		TicketId  string `json:"ticketId"`
+		Reference string `json:"reference"`
+	}
+	_ = json.Unmarshal(bodyBytes, &ack)
+	ticketID := ack.TicketID
+	if ticketID == "" {
+		ticketID = ack.TicketId
+	}
+	if ticketID == "" {
+		ticketID = ack.Reference
+	}
+	return ticketID, resp.StatusCode, body, nil
+}
+
+func failForward(ctx context.Context, job models.ForwardJob, statusCode int, body string, sendErr error) {
+	attempts := job.Attempts + 1
+	var perm permanentForwardError
+	final := errors.As(sendErr, &perm) || attempts >= forwardCfg.MaxAttempts
+
+	// A rejected request still means the external system is reachable.
+	if errors.As(sendErr, &perm) {
+		forwardBreaker.Success()
+	} else {
+		forwardBreaker.Failure()
+	}
+
+	set := bson.M{
+		"attempts":       attempts,
+		"external_error": sendErr.Error(),
+	}
+	if statusCode != 0 {
+		set["external_status_code"] = statusCode
+		set["external_response"] = body
+	}
+
+	if !final {
+		next := time.Now().Add(forwardCfg.backoff(attempts))
+		set["status"] = models.ForwardStatusQueued
+		set["next_attempt_at"] = next
+		_, _ = db.Collection(forwardCollection).UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{
+			"$set":   set,
+			"$unset": bson.M{"locked_until": "", "locked_by": ""},
+		})
+		log.Printf("[WARN] Forward %s failed (attempt %d/%d), retry at %s: %v",
+			job.ID.Hex(), attempts, forwardCfg.MaxAttempts, next.Format(time.RFC3339), sendErr)
+		return
+	}
+
+	now := time.Now()
+	set["status"] = models.ForwardStatusFailed
+	set["completed_at"] = now
+	_, _ = db.Collection(forwardCollection).UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"locked_until": "", "locked_by": ""},
+	})
+	_, _ = db.Collection("reports").UpdateOne(ctx, bson.M{"_id": job.ReportID}, bson.M{
+		"$push": bson.M{"timeline": timelineEntry("system", "forward_failed", "", sendErr.Error(), job.ID.Hex())},
+	})
+
+	job.Attempts = attempts
+	recordSystemAudit("SYSTEM_FORWARDER", models.AuditActionForward, job.ReportID.Hex(), forwardAuditDetails(job, "FAILED", sendErr.Error()))
+	log.Printf("[ERROR] Forward %s failed permanently after %d attempt(s): %v", job.ID.Hex(), attempts, sendErr)
+}
+
+func completeForward(ctx context.Context, job models.ForwardJob, ticketID string, statusCode int, body string) {
+	now := time.Now()
+	job.ExternalTicketID = ticketID
+	job.Attempts++
+
+	_, _ = db.Collection(forwardCollection).UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{
+		"$set": bson.M{
+			"status":               models.ForwardStatusSuccess,
+			"attempts":             job.Attempts,
+			"completed_at":         now,
+			"external_status_code": statusCode,
+			"external_response":    body,
+			"external_ticket_id":   ticketID,
+		},
+		"$unset": bson.M{"locked_until": "", "locked_by": "", "external_error": ""},
+	})
+
+	history := bson.M{
+		"forward_id":         job.ID.Hex(),
+		"forward_to":         job.ForwardTo,
+		"forwarded_by":       job.ForwardedBy,
+		"notes":              job.Notes,
+		"forwarded_at":       job.ForwardedAt,
+		"status":             models.ForwardStatusSuccess,
+		"external_ticket_id": ticketID,
+	}
+	set := bson.M{
+		"forwarded_to": job.ForwardTo,
+		"forwarded_at": job.ForwardedAt,
+		"updated_at":   now,
+	}
+	if ticketID != "" {
+		set["external_ticket_id"] = ticketID
+	}
+	update := bson.M{
+		"$set": set,
+		"$push": bson.M{
+			"forward_history": history,
+			"timeline":        timelineEntry("system", "forwarded", "", "Diterima oleh "+job.ForwardTo, ticketID),
+		},
+	}
+
+	if _, err := updateReportAndNotify(ctx, bson.M{"_id": job.ReportID}, update, "Laporan Diteruskan", "IN_PROGRESS"); err != nil {
+		log.Printf("[ERROR] Forward %s delivered but report update failed: %v", job.ID.Hex(), err)
+	}
+
+	recordSystemAudit("SYSTEM_FORWARDER", models.AuditActionForward, job.ReportID.Hex(), forwardAuditDetails(job, "SUCCESS", ""))
+	log.Printf("[OK] Forward %s delivered to %s (ticket %s)", job.ID.Hex(), job.ForwardTo, ticketID)
+}
+
+func forwardCallbackSecret() string {
+	return strings.TrimSpace(os.Getenv("FORWARD_CALLBACK_SECRET"))
+}
+
+// verifyCallbackSignature checks X-Signature (hex HMAC-SHA256 over
+// "<timestamp>.<body>") and rejects timestamps outside the allowed skew.
+func verifyCallbackSignature(r *http.Request, body []byte) error {
+	secret := forwardCallbackSecret()
+	if secret == "" {
+		return errors.New("callback secret not configured")
+	}
+
+	tsHeader := r.Header.Get("X-Signature-Timestamp")
+	ts, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return errors.New("missing or invalid timestamp")
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew > forwardCallbackMaxSkew || skew < -forwardCallbackMaxSkew {
+		return errors.New("timestamp outside allowed window")
+	}
+
+	sig := strings.TrimPrefix(r.Header.Get("X-Signature"), "sha256=")
+	given, err := hex.DecodeString(sig)
+	if err != nil || len(given) == 0 {
+		return errors.New("missing or invalid signature")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(tsHeader))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	if !hmac.Equal(given, mac.Sum(nil)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// mapExternalStatus translates an agency's status vocabulary onto ours. An
+// empty result means the update is recorded on the timeline only.
+func mapExternalStatus(status string) string {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "received", "acknowledged", "accepted", "assigned", "in_progress", "processing", "diproses":
+		return "IN_PROGRESS"
+	case "resolved", "done", "closed", "completed", "selesai":
+		return "RESOLVED"
+	case "rejected", "declined", "invalid", "ditolak":
+		return "REJECTED"
+	default:
+		return ""
+	}
+}
+
+// externalForwardCallbackHandler receives status updates pushed by the
+// external agency for a ticket we forwarded.
+func externalForwardCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, "Method not allowed", "")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	if err := verifyCallbackSignature(r, body); err != nil {
+		middleware.LogError(middleware.GetTraceID(r), "Rejected forward callback from "+r.RemoteAddr, err)
+		response.Error(w, http.StatusUnauthorized, "Invalid signature", "")
+		return
+	}
+
+	var input struct {
+		EventID    string    `json:"event_id"`
+		TicketID   string    `json:"ticket_id"`
+		Status     string    `json:"status"`
+		Note       string    `json:"note"`
+		OccurredAt time.Time `json:"occurred_at"`
+	}
+	if err := json.Unmarshal(body, &input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if input.EventID == "" || input.TicketID == "" || input.Status == "" {
+		response.Error(w, http.StatusBadRequest, "event_id, ticket_id and status are required", "")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var job models.ForwardJob
+	err = db.Collection(forwardCollection).FindOne(ctx, bson.M{"external_ticket_id": input.TicketID},
+		options.FindOne().SetSort(bson.D{{Key: "forwarded_at", Value: -1}}),
+	).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			response.Error(w, http.StatusNotFound, "Unknown ticket", "")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to look up ticket", err.Error())
+		return
+	}
+
+	entry := timelineEntry(job.ForwardTo, "external_status", input.Status, input.Note, input.TicketID)
+	if !input.OccurredAt.IsZero() {
+		entry.At = input.OccurredAt
+	}
+
+	mapped := mapExternalStatus(input.Status)
+	set := bson.M{"updated_at": time.Now()}
+	if mapped != "" {
+		set["status"] = mapped
+	}
+	update := bson.M{"$set": set, "$push": bson.M{"timeline": entry}}
+
+	// The event ID is recorded in the same transaction as the update, so a
+	// callback that fails half way is processed again when the agency retries.
+	err = runInTransaction(ctx, func(ctx context.Context) error {
+		_, err := db.Collection(forwardCallbackCollection).InsertOne(ctx, bson.M{
+			"event_id":    input.EventID,
+			"ticket_id":   input.TicketID,
+			"report_id":   job.ReportID,
+			"status":      input.Status,
+			"received_at": time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := db.Collection(forwardCollection).UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": bson.M{"external_status": input.Status}}); err != nil {
+			return err
+		}
+		if mapped != "" {
+			_, err = updateReportAndNotifyTx(ctx, bson.M{"_id": job.ReportID}, update, "Update dari "+job.ForwardTo, mapped)
+			return err
+		}
+		_, err = db.Collection("reports").UpdateOne(ctx, bson.M{"_id": job.ReportID}, update)
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		response.Success(w, http.StatusOK, "Callback already processed", nil)
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to update report", err.Error())
+		return
+	}
+
+	details := map[string]string{
+		"ticket_id":       input.TicketID,
+		"external_status": input.Status,
+		"event_id":        input.EventID,
+	}
+	if mapped != "" {
+		details["status"] = mapped
+	}
+	writeAudit(middleware.GetTraceID(r), "external:"+job.ForwardTo, "external", "", models.AuditActionStatusChange, []string{job.ReportID.Hex()}, details)
+
+	response.Success(w, http.StatusOK, "Callback processed", map[string]interface{}{
+		"report_id": job.ReportID.Hex(),
+		"status":    mapped,
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	// Each step acts on the breaker and checks the state it leaves behind.
+	// "cooldown" moves openUntil into the past instead of sleeping.
+	const (
+		allow    = "allow"
+		deny     = "deny"
+		success  = "success"
+		failure  = "failure"
+		release  = "release"
+		cooldown = "cooldown"
+	)
+	type step struct {
+		op        string
+		wantState string
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "stays closed below the threshold",
+			steps: []step{
+				{failure, "closed"},
+				{failure, "closed"},
+				{allow, "closed"},
+				{success, "closed"},
+				{failure, "closed"},
+				{failure, "closed"},
+				{allow, "closed"},
+			},
+		},
+		{
+			name: "opens at the threshold and rejects during cooldown",
+			steps: []step{
+				{failure, "closed"},
+				{failure, "closed"},
+				{failure, "open"},
+				{deny, "open"},
+				{deny, "open"},
+			},
+		},
+		{
+			name: "half-open lets one trial through",
+			steps: []step{
+				{failure, "closed"},
+				{failure, "closed"},
+				{failure, "open"},
+				{cooldown, "half-open"},
+				{allow, "half-open"},
+				{deny, "half-open"},
+			},
+		},
+		{
+			name: "successful trial closes",
+			steps: []step{
+				{failure, "closed"},
+				{failure, "closed"},
+				{failure, "open"},
+				{cooldown, "half-open"},
+				{allow, "half-open"},
+				{success, "closed"},
+				{allow, "closed"},
+				{failure, "closed"},
+			},
+		},
+		{
+			name: "failed trial reopens for another cooldown",
+			steps: []step{
+				{failure, "closed"},
+				{failure, "closed"},
+				{failure, "open"},
+				{cooldown, "half-open"},
+				{allow, "half-open"},
+				{failure, "open"},
+				{deny, "open"},
+				{cooldown, "half-open"},
+				{allow, "half-open"},
+			},
+		},
+		{
+			name: "released trial can be taken again",
+			steps: []step{
+				{failure, "closed"},
+				{failure, "closed"},
+				{failure, "open"},
+				{cooldown, "half-open"},
+				{allow, "half-open"},
+				{release, "half-open"},
+				{allow, "half-open"},
+				{deny, "half-open"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &circuitBreaker{threshold: 3, cooldown: time.Hour}
+			for i, s := range tt.steps {
+				switch s.op {
+				case allow:
+					if !b.Allow() {
+						t.Fatalf("step %d: Allow() = false, want true", i)
+					}
+				case deny:
+					if b.Allow() {
+						t.Fatalf("step %d: Allow() = true, want false", i)
+					}
+				case success:
+					b.Success()
+				case failure:
+					b.Failure()
+				case release:
+					b.Release()
+				case cooldown:
+					b.openUntil = time.Now().Add(-time.Second)
+				}
+				state := b.State()
+				if got := state["state"]; got != s.wantState {
+					t.Fatalf("step %d (%s): state = %v, want %s", i, s.op, got, s.wantState)
+				}
+				if _, ok := state["retry_at"]; ok != (s.wantState == "open") {
+					t.Errorf("step %d (%s): retry_at present = %v in state %s", i, s.op, ok, s.wantState)
+				}
+			}
+		})
+	}
+}
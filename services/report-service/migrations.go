@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/security"
+	"citizen-reporting-system/services/report-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrateAnonymousReporterIDs rewrites reporter IDs created with the old
+// salted SHA-256 scheme, or keyed with a previous ANON_ID_SECRET, to the
+// current keyed pseudonym. It is idempotent and runs on every start; reports
+// without an encrypted identity cannot be re-keyed, are only counted and
+// stay reachable through legacyAnonymousID.
+func migrateAnonymousReporterIDs() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cursor, err := db.Collection("reports").Find(ctx,
+		bson.M{"is_anonymous": true},
+		options.Find().SetProjection(bson.M{"reporter_id": 1, "reporter_id_enc": 1, "anon_period": 1, "created_at": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	period := security.PseudonymPeriod()
+	migrated := 0
+	skipped := 0
+
+	for cursor.Next(ctx) {
+		var report models.Report
+		if err := cursor.Decode(&report); err != nil {
+			return err
+		}
+
+		rekeyed := strings.HasPrefix(report.ReporterID, security.PseudonymPrefix)
+		if strings.TrimSpace(report.ReporterIDEnc) == "" {
+			if !rekeyed {
+				skipped++
+			}
+			continue
+		}
+
+		userID, err := security.DecryptString(report.ReporterIDEnc)
+		if err != nil || userID == "" {
+			log.Printf("[WARN] Migration: cannot recover identity for anonymous report %s: %v", report.ID.Hex(), err)
+			skipped++
+			continue
+		}
+
+		label := security.PeriodLabel(period, report.CreatedAt)
+		if rekeyed {
+			label = report.AnonPeriod
+		}
+		newID := security.PseudonymousID(userID, label)
+		if newID == report.ReporterID {
+			continue
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"reporter_id": newID,
+				"anon_period": label,
+			},
+		}
+		if _, err := db.Collection("reports").UpdateOne(ctx, bson.M{"_id": report.ID, "reporter_id": report.ReporterID}, update); err != nil {
+			return err
+		}
+		migrated++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if migrated > 0 || skipped > 0 {
+		log.Printf("[OK] Migration: re-keyed %d anonymous reporter IDs (%d without recoverable identity)", migrated, skipped)
+	}
+	return nil
+}
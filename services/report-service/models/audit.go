@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AuditActionRead         = "report.read"
+	AuditActionStatusChange = "report.status_change"
+	AuditActionForward      = "report.forward"
+	AuditActionEscalate     = "report.escalate"
+	AuditActionDispatch     = "report.dispatch"
+	AuditActionExport       = "audit.export"
+	AuditActionWebhook      = "webhook.change"
+)
+
+type AuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Seq        int64              `bson:"seq,omitempty" json:"seq,omitempty"`
+	PrevHash   string             `bson:"prev_hash,omitempty" json:"prev_hash,omitempty"`
+	Hash       string             `bson:"hash,omitempty" json:"hash,omitempty"`
+	Timestamp  time.Time          `bson:"timestamp" json:"timestamp"`
+	ActorID    string             `bson:"actor_id" json:"actor_id"`
+	ActorRole  string             `bson:"actor_role,omitempty" json:"actor_role,omitempty"`
+	Department string             `bson:"department,omitempty" json:"department,omitempty"`
+	ReportID   string             `bson:"report_id,omitempty" json:"report_id,omitempty"`
+	// ReportIDs is set instead of ReportID on entries covering many reports,
+	// such as one page of a list read.
+	ReportIDs []string          `bson:"report_ids,omitempty" json:"report_ids,omitempty"`
+	Action    string            `bson:"action" json:"action"`
+	TraceID   string            `bson:"trace_id,omitempty" json:"trace_id,omitempty"`
+	Details   map[string]string `bson:"details,omitempty" json:"details,omitempty"`
+}
+
+type AuditCheckpoint struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Seq       int64              `bson:"seq" json:"seq"`
+	Hash      string             `bson:"hash" json:"hash"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	KeyID     string             `bson:"key_id" json:"key_id"`
+	Signature string             `bson:"signature" json:"signature"`
+}
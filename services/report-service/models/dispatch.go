@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	ReceiptDelivered = "DELIVERED"
+	ReceiptQueued    = "QUEUED"
+	ReceiptHandled   = "HANDLED"
+)
+
+type DispatchReceipt struct {
+	Department  string     `bson:"department" json:"department"`
+	Connector   string     `bson:"connector" json:"connector"`
+	Status      string     `bson:"status" json:"status"`
+	Reference   string     `bson:"reference,omitempty" json:"reference,omitempty"`
+	Detail      string     `bson:"detail,omitempty" json:"detail,omitempty"`
+	Attempts    int        `bson:"attempts" json:"attempts"`
+	DeliveredAt time.Time  `bson:"delivered_at" json:"delivered_at"`
+	RecordedAt  time.Time  `bson:"recorded_at" json:"recorded_at"`
+	HandledBy   string     `bson:"handled_by,omitempty" json:"handled_by,omitempty"`
+	HandledAt   *time.Time `bson:"handled_at,omitempty" json:"handled_at,omitempty"`
+}
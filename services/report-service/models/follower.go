@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	FollowSourceManual = "manual"
+	FollowSourceUpvote = "upvote"
+)
+
+// ReportFollower subscribes a user to updates on a public report. Follows
+// made by upvoting are dropped again when the upvote is withdrawn.
+type ReportFollower struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	ReportID  primitive.ObjectID `bson:"report_id" json:"report_id"`
+	UserID    string             `bson:"user_id" json:"user_id"`
+	Source    string             `bson:"source" json:"source"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ForwardStatusQueued  = "QUEUED"
+	ForwardStatusSending = "SENDING"
+	ForwardStatusSuccess = "SUCCESS"
+	ForwardStatusFailed  = "FAILED"
+)
+
+// ForwardJob is one forwarding request in the forwarded_reports collection,
+// processed asynchronously by the forward worker.
+type ForwardJob struct {
+	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ReportID           primitive.ObjectID `bson:"report_id" json:"report_id"`
+	ForwardTo          string             `bson:"forward_to" json:"forward_to"`
+	ForwardedBy        string             `bson:"forwarded_by" json:"forwarded_by"`
+	RequestedBy        string             `bson:"requested_by,omitempty" json:"requested_by,omitempty"`
+	Notes              string             `bson:"notes" json:"notes"`
+	ForwardedAt        time.Time          `bson:"forwarded_at" json:"forwarded_at"`
+	Status             string             `bson:"status" json:"status"`
+	ExternalURL        string             `bson:"external_url" json:"external_url"`
+	Attempts           int                `bson:"attempts" json:"attempts"`
+	NextAttemptAt      time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil        *time.Time         `bson:"locked_until,omitempty" json:"-"`
+	LockedBy           string             `bson:"locked_by,omitempty" json:"-"`
+	AttemptedAt        *time.Time         `bson:"attempted_at,omitempty" json:"attempted_at,omitempty"`
+	CompletedAt        *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ExternalStatusCode int                `bson:"external_status_code,omitempty" json:"external_status_code,omitempty"`
+	ExternalResponse   string             `bson:"external_response,omitempty" json:"external_response,omitempty"`
+	ExternalError      string             `bson:"external_error,omitempty" json:"external_error,omitempty"`
+	ExternalTicketID   string             `bson:"external_ticket_id,omitempty" json:"external_ticket_id,omitempty"`
+	ExternalStatus     string             `bson:"external_status,omitempty" json:"external_status,omitempty"`
+	TraceID            string             `bson:"trace_id,omitempty" json:"trace_id,omitempty"`
+	TraceContext       map[string]string  `bson:"trace_context,omitempty" json:"-"`
+}
+
+type TimelineEntry struct {
+	At     time.Time `bson:"at" json:"at"`
+	Source string    `bson:"source" json:"source"`
+	Event  string    `bson:"event" json:"event"`
+	Status string    `bson:"status,omitempty" json:"status,omitempty"`
+	Note   string    `bson:"note,omitempty" json:"note,omitempty"`
+	Ref    string    `bson:"ref,omitempty" json:"ref,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	OutboxStatusPending = "PENDING"
+	OutboxStatusSent    = "SENT"
+)
+
+type OutboxEvent struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	EventType     string             `bson:"event_type" json:"event_type"`
+	AggregateID   string             `bson:"aggregate_id,omitempty" json:"aggregate_id,omitempty"`
+	Exchange      string             `bson:"exchange" json:"exchange"`
+	RoutingKey    string             `bson:"routing_key" json:"routing_key"`
+	ContentType   string             `bson:"content_type" json:"content_type"`
+	Payload       string             `bson:"payload" json:"payload"`
+	Headers       map[string]string  `bson:"headers,omitempty" json:"headers,omitempty"`
+	Status        string             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil   *time.Time         `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	LockedBy      string             `bson:"locked_by,omitempty" json:"locked_by,omitempty"`
+	SentAt        *time.Time         `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+}
@@ -11,33 +11,37 @@ type Report struct {
 	Title               string             `bson:"title" json:"title"`
 	Description         string             `bson:"description" json:"description"`
 	Category            string             `bson:"category" json:"category"`
+	Subcategory         string             `bson:"subcategory,omitempty" json:"subcategory,omitempty"`
+	Region              string             `bson:"region,omitempty" json:"region,omitempty"`
+	Priority            string             `bson:"priority,omitempty" json:"priority,omitempty"`
 	Location            string             `bson:"location,omitempty" json:"location,omitempty"`
 	IsAnonymous         bool               `bson:"is_anonymous" json:"is_anonymous"`
 	IsPublic            bool               `bson:"is_public" json:"is_public"`
 	AssignedDepartments []string           `bson:"assigned_departments" json:"assigned_departments"`
 	ReporterID          string             `bson:"reporter_id" json:"reporter_id"`
 	ReporterIDEnc string     `bson:"reporter_id_enc,omitempty" json:"-"`
+	AnonPeriod    string     `bson:"anon_period,omitempty" json:"-"`
 	Reporter      string     `bson:"reporter_name" json:"reporter_name"`
 	ImageURL      string     `bson:"image_url,omitempty" json:"image_url,omitempty"`
 	Status        string     `bson:"status" json:"status"`
 	Upvotes       int        `bson:"upvotes" json:"upvotes"`
 	UpvotedBy     []string   `bson:"upvoted_by,omitempty" json:"-"`
 	HasUpvoted    bool       `bson:"-" json:"has_upvoted,omitempty"`
+	IsFollowing   bool       `bson:"-" json:"is_following,omitempty"`
 	CreatedAt     time.Time  `bson:"created_at" json:"created_at"`
 	UpdatedAt     time.Time  `bson:"updated_at" json:"updated_at"`
 	SlaDeadline   *time.Time `bson:"sla_deadline,omitempty" json:"sla_deadline,omitempty"`
+	SlaWarnings   []int      `bson:"sla_warnings,omitempty" json:"sla_warnings,omitempty"`
 	IsEscalated   bool       `bson:"is_escalated" json:"is_escalated"`
 	EscalatedAt   *time.Time `bson:"escalated_at,omitempty" json:"escalated_at,omitempty"`
 	EscalatedBy   string     `bson:"escalated_by,omitempty" json:"escalated_by,omitempty"`
-}
 
-type ReportEvent struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Category    string    `json:"category"`
-	IsAnonymous bool      `json:"is_anonymous"`
-	ReporterID  string    `json:"reporter_id"`
-	Reporter    string    `json:"reporter_name"`
-	CreatedAt   time.Time `json:"created_at"`
+	DispatchedAt     *time.Time        `bson:"dispatched_at,omitempty" json:"dispatched_at,omitempty"`
+	DispatchReceipts []DispatchReceipt `bson:"dispatch_receipts,omitempty" json:"dispatch_receipts,omitempty"`
+	ForwardedTo      string            `bson:"forwarded_to,omitempty" json:"forwarded_to,omitempty"`
+	ExternalTicketID string            `bson:"external_ticket_id,omitempty" json:"external_ticket_id,omitempty"`
+	Timeline         []TimelineEntry   `bson:"timeline,omitempty" json:"timeline,omitempty"`
+	// UpvoteMilestones are the upvote counts the reporter was told about.
+	UpvoteMilestones []int `bson:"upvote_milestones,omitempty" json:"-"`
 }
+
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	WebhookEventCreated       = "report.created"
+	WebhookEventStatusChanged = "report.status_changed"
+	WebhookEventEscalated     = "report.escalated"
+	WebhookEventResolved      = "report.resolved"
+
+	WebhookDeliveryPending = "PENDING"
+	WebhookDeliverySuccess = "SUCCESS"
+	WebhookDeliveryFailed  = "FAILED"
+)
+
+var WebhookEventTypes = []string{WebhookEventCreated, WebhookEventStatusChanged, WebhookEventEscalated, WebhookEventResolved}
+
+type WebhookSubscription struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	URL         string             `bson:"url" json:"url"`
+	Secret      string             `bson:"secret" json:"-"`
+	EventTypes  []string           `bson:"event_types" json:"event_types"`
+	Departments []string           `bson:"departments,omitempty" json:"departments,omitempty"`
+	Categories  []string           `bson:"categories,omitempty" json:"categories,omitempty"`
+	Active      bool               `bson:"active" json:"active"`
+	CreatedBy   string             `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type WebhookAttempt struct {
+	At         time.Time `bson:"at" json:"at"`
+	StatusCode int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error      string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+type WebhookDelivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubscriptionID primitive.ObjectID `bson:"subscription_id" json:"subscription_id"`
+	EventID        string             `bson:"event_id" json:"event_id"`
+	EventType      string             `bson:"event_type" json:"event_type"`
+	ReportID       string             `bson:"report_id" json:"report_id"`
+	Payload        string             `bson:"payload" json:"payload"`
+	Status         string             `bson:"status" json:"status"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	AttemptLog     []WebhookAttempt   `bson:"attempt_log,omitempty" json:"attempt_log,omitempty"`
+	NextAttemptAt  time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil    *time.Time         `bson:"locked_until,omitempty" json:"-"`
+	LockedBy       string             `bson:"locked_by,omitempty" json:"-"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	DeliveredAt    *time.Time         `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	RedeliveredBy  string             `bson:"redelivered_by,omitempty" json:"redelivered_by,omitempty"`
+	TraceContext   map[string]string  `bson:"trace_context,omitempty" json:"-"`
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"citizen-reporting-system/pkg/events"
+	"citizen-reporting-system/pkg/queue"
+	"citizen-reporting-system/pkg/telemetry"
+	"citizen-reporting-system/services/report-service/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	outboxCollection    = "outbox"
+	outboxBatchSize     = 100
+	outboxLockDuration  = 30 * time.Second
+	outboxMaxBackoff    = 5 * time.Minute
+	outboxSentRetention = 7 * 24 * time.Hour
+	eventSource         = "/report-service"
+)
+
+var (
+	outboxPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_pending_events",
+		Help: "Number of outbox events not yet published",
+	})
+	outboxLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_lag_seconds",
+		Help: "Age in seconds of the oldest unpublished outbox event",
+	})
+	outboxPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_published_total",
+		Help: "Outbox events published and confirmed by the broker",
+	}, []string{"event_type"})
+	outboxPublishErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_publish_errors_total",
+		Help: "Failed outbox publish attempts",
+	}, []string{"event_type"})
+	outboxPublishLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "outbox_publish_delay_seconds",
+		Help:    "Delay between an outbox event being written and being confirmed by the broker",
+		Buckets: []float64{0.05, 0.1, 0.5, 1, 2, 5, 15, 60, 300, 1800},
+	})
+
+	outboxInstanceID = outboxInstance()
+)
+
+func outboxInstance() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "report-service"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func registerOutboxMetrics() {
+	prometheus.MustRegister(outboxPending, outboxLag, outboxPublished, outboxPublishErrors, outboxPublishLatency)
+}
+
+func ensureOutboxIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(outboxCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}, {Key: "created_at", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "sent_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(outboxSentRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+// newOutboxEvent wraps data in a CloudEvents envelope of eventType and stores
+// it ready to publish in structured mode.
+func newOutboxEvent(eventType, aggregateID, exchange, routingKey string, data interface{}) (models.OutboxEvent, error) {
+	env, err := events.New(eventSource, eventType, aggregateID, data)
+	if err != nil {
+		return models.OutboxEvent{}, err
+	}
+	body, err := json.Marshal(env)
+	if err != nil {
+		return models.OutboxEvent{}, err
+	}
+	now := time.Now()
+	return models.OutboxEvent{
+		EventType:     eventType,
+		AggregateID:   aggregateID,
+		Exchange:      exchange,
+		RoutingKey:    routingKey,
+		ContentType:   events.ContentType,
+		Payload:       string(body),
+		Headers:       env.Headers(),
+		Status:        models.OutboxStatusPending,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
+
+func enqueueOutbox(ctx context.Context, events ...models.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, 0, len(events))
+	for _, e := range events {
+		// Carry the request's trace context so the relay's publish span and
+		// the consumers join the trace that caused the event.
+		if e.Headers == nil {
+			e.Headers = map[string]string{}
+		}
+		telemetry.Inject(ctx, e.Headers)
+		docs = append(docs, e)
+	}
+	_, err := db.Collection(outboxCollection).InsertMany(ctx, docs)
+	return err
+}
+
+// requireTransactions fails unless the deployment can run multi-document
+// transactions, which the outbox depends on: a replica set or a sharded
+// cluster. A standalone mongod would let a state change commit without its
+// events.
+func requireTransactions() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := db.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return err
+	}
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return errors.New("MongoDB is a standalone server; run it as a replica set (e.g. --replSet rs0) so outbox writes are transactional")
+	}
+	return nil
+}
+
+// runInTransaction executes fn inside a multi-document transaction so a state
+// change and its outbox events commit together.
+func runInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
+
+type outboxRelay struct {
+	broker *queue.Manager
+}
+
+func (r *outboxRelay) publish(ctx context.Context, event models.OutboxEvent) error {
+	headers := amqp.Table{}
+	for k, v := range event.Headers {
+		headers[k] = v
+	}
+
+	ctx = telemetry.Extract(ctx, event.Headers)
+	return r.broker.Publish(ctx, event.Exchange, event.RoutingKey, false, amqp.Publishing{
+		ContentType:  event.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.ID.Hex(),
+		Type:         event.EventType,
+		Timestamp:    event.CreatedAt,
+		Headers:      headers,
+		Body:         []byte(event.Payload),
+	})
+}
+
+func outboxPollInterval() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("OUTBOX_POLL_INTERVAL")); err == nil && d > 0 {
+		return d
+	}
+	return time.Second
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	if attempts > 16 {
+		return outboxMaxBackoff
+	}
+	d := time.Second << uint(attempts)
+	if d > outboxMaxBackoff {
+		d = outboxMaxBackoff
+	}
+	return d
+}
+
+// startOutboxRelay publishes pending outbox events with publisher confirms.
+// Each event is claimed with a short lease so several replicas can relay
+// concurrently; failed events are retried with capped exponential backoff.
+func startOutboxRelay(broker *queue.Manager) {
+	relay := &outboxRelay{broker: broker}
+	ticker := time.NewTicker(outboxPollInterval())
+	defer ticker.Stop()
+
+	log.Println("[INFO] Outbox relay started")
+
+	for range ticker.C {
+		if !broker.Health().Connected {
+			updateOutboxMetrics()
+			continue
+		}
+		for i := 0; i < outboxBatchSize; i++ {
+			if !relay.relayOne() {
+				break
+			}
+		}
+		updateOutboxMetrics()
+	}
+}
+
+func (r *outboxRelay) relayOne() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	lockedUntil := now.Add(outboxLockDuration)
+
+	var event models.OutboxEvent
+	err := db.Collection(outboxCollection).FindOneAndUpdate(ctx,
+		bson.M{
+			"status":          models.OutboxStatusPending,
+			"next_attempt_at": bson.M{"$lte": now},
+			"$or": []bson.M{
+				{"locked_until": bson.M{"$exists": false}},
+				{"locked_until": bson.M{"$lt": now}},
+			},
+		},
+		bson.M{"$set": bson.M{"locked_until": lockedUntil, "locked_by": outboxInstanceID}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&event)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("[ERROR] Outbox: failed to claim event: %v", err)
+		}
+		return false
+	}
+
+	if err := r.publish(ctx, event); err != nil {
+		outboxPublishErrors.WithLabelValues(event.EventType).Inc()
+		attempts := event.Attempts + 1
+		next := time.Now().Add(outboxBackoff(attempts))
+		_, _ = db.Collection(outboxCollection).UpdateOne(ctx,
+			bson.M{"_id": event.ID, "locked_by": outboxInstanceID},
+			bson.M{
+				"$set":   bson.M{"attempts": attempts, "last_error": err.Error(), "next_attempt_at": next},
+				"$unset": bson.M{"locked_until": "", "locked_by": ""},
+			},
+		)
+		log.Printf("[WARN] Outbox: publish of %s (%s) failed, attempt %d, retry at %s: %v",
+			event.ID.Hex(), event.EventType, attempts, next.Format(time.RFC3339), err)
+		return false
+	}
+
+	sentAt := time.Now()
+	_, err = db.Collection(outboxCollection).UpdateOne(ctx,
+		bson.M{"_id": event.ID},
+		bson.M{
+			"$set":   bson.M{"status": models.OutboxStatusSent, "sent_at": sentAt, "attempts": event.Attempts + 1},
+			"$unset": bson.M{"locked_until": "", "locked_by": "", "last_error": ""},
+		},
+	)
+	if err != nil {
+		log.Printf("[ERROR] Outbox: event %s published but not marked sent: %v", event.ID.Hex(), err)
+	}
+
+	outboxPublished.WithLabelValues(event.EventType).Inc()
+	outboxPublishLatency.Observe(sentAt.Sub(event.CreatedAt).Seconds())
+	return true
+}
+
+func updateOutboxMetrics() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pendingFilter := bson.M{"status": models.OutboxStatusPending}
+	count, err := db.Collection(outboxCollection).CountDocuments(ctx, pendingFilter)
+	if err != nil {
+		return
+	}
+	outboxPending.Set(float64(count))
+
+	var oldest models.OutboxEvent
+	err = db.Collection(outboxCollection).FindOne(ctx, pendingFilter,
+		options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	).Decode(&oldest)
+	if err != nil {
+		outboxLag.Set(0)
+		return
+	}
+	outboxLag.Set(time.Since(oldest.CreatedAt).Seconds())
+}
+
+func outboxStats(ctx context.Context) map[string]interface{} {
+	pending, _ := db.Collection(outboxCollection).CountDocuments(ctx, bson.M{"status": models.OutboxStatusPending})
+	stats := map[string]interface{}{
+		"pending": pending,
+	}
+
+	var oldest models.OutboxEvent
+	err := db.Collection(outboxCollection).FindOne(ctx, bson.M{"status": models.OutboxStatusPending},
+		options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	).Decode(&oldest)
+	if err == nil {
+		stats["lag_seconds"] = time.Since(oldest.CreatedAt).Seconds()
+	}
+	return stats
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/events"
+	"citizen-reporting-system/pkg/telemetry"
+	"citizen-reporting-system/services/report-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	slaWarningThresholds = loadSLAWarningThresholds()
+	errSLAWarningSent    = errors.New("sla warning already sent")
+)
+
+// loadSLAWarningThresholds parses SLA_WARNING_THRESHOLDS, percentages of the
+// SLA window such as "75,90". Invalid entries are ignored.
+func loadSLAWarningThresholds() []int {
+	v := os.Getenv("SLA_WARNING_THRESHOLDS")
+	if strings.TrimSpace(v) == "" {
+		v = "75,90"
+	}
+	seen := map[int]bool{}
+	var out []int
+	for _, part := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), "%")))
+		if err != nil || n <= 0 || n >= 100 {
+			log.Printf("[WARN] Ignoring SLA warning threshold %q", part)
+			continue
+		}
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// slaElapsedPercent is how much of the report's SLA window has passed.
+func slaElapsedPercent(createdAt, deadline, now time.Time) float64 {
+	total := deadline.Sub(createdAt)
+	if total <= 0 {
+		return 100
+	}
+	return float64(now.Sub(createdAt)) / float64(total) * 100
+}
+
+// checkSLAWarnings warns the assigned departments as open reports cross
+// each threshold. It runs with the auto-escalation worker.
+func checkSLAWarnings() {
+	if len(slaWarningThresholds) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{
+		"status":       bson.M{"$in": []string{"PENDING", "DISPATCHED", "IN_PROGRESS"}},
+		"sla_deadline": bson.M{"$gt": now},
+		"is_escalated": bson.M{"$ne": true},
+		"sla_warnings": bson.M{"$not": bson.M{"$all": slaWarningThresholds}},
+	}
+
+	cursor, err := db.Collection("reports").Find(ctx, filter)
+	if err != nil {
+		log.Printf("[ERROR] SLA warnings: failed to fetch reports: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var reports []models.Report
+	if err := cursor.All(ctx, &reports); err != nil {
+		log.Printf("[ERROR] SLA warnings: failed to decode reports: %v", err)
+		return
+	}
+
+	for _, report := range reports {
+		if report.SlaDeadline == nil {
+			continue
+		}
+		elapsed := slaElapsedPercent(report.CreatedAt, *report.SlaDeadline, now)
+		var crossed []int
+		for _, t := range slaWarningThresholds {
+			if elapsed >= float64(t) && !containsInt(report.SlaWarnings, t) {
+				crossed = append(crossed, t)
+			}
+		}
+		if len(crossed) > 0 {
+			sendSLAWarning(report, crossed)
+		}
+	}
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// sendSLAWarning records the crossed thresholds and queues one warning for
+// the highest of them, in one transaction. The filter on the highest
+// threshold makes concurrent workers send it only once. Lower thresholds
+// passed while the worker was down get no warning of their own; the one
+// warning names them all.
+func sendSLAWarning(report models.Report, crossed []int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	threshold := crossed[len(crossed)-1]
+	ctx, span := telemetry.Tracer().Start(ctx, "sla.warning", trace.WithAttributes(
+		attribute.String("report.id", report.ID.Hex()),
+		attribute.Int("sla.threshold", threshold),
+	))
+	defer span.End()
+
+	err := runInTransaction(ctx, func(ctx context.Context) error {
+		res, err := db.Collection("reports").UpdateOne(ctx,
+			bson.M{"_id": report.ID, "sla_warnings": bson.M{"$ne": threshold}},
+			bson.M{"$addToSet": bson.M{"sla_warnings": bson.M{"$each": crossed}}},
+		)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return errSLAWarningSent
+		}
+		event, err := slaWarningNotificationEvent(report, crossed)
+		if err != nil {
+			return err
+		}
+		return enqueueOutbox(ctx, event)
+	})
+	if err != nil {
+		if !errors.Is(err, errSLAWarningSent) {
+			telemetry.RecordError(span, err)
+			log.Printf("[ERROR] SLA warnings: failed to warn on report %s: %v", report.ID.Hex(), err)
+		}
+		return
+	}
+
+	log.Printf("[INFO] SLA warning: report %s passed %d%% of its SLA", report.ID.Hex(), threshold)
+}
+
+// slaWarningNotificationEvent warns about the highest crossed threshold and
+// lists the lower ones crossed since the last check.
+func slaWarningNotificationEvent(report models.Report, crossed []int) (models.OutboxEvent, error) {
+	reportID := report.ID.Hex()
+	threshold := crossed[len(crossed)-1]
+	deadline := report.SlaDeadline.In(slaLocation()).Format("02 Jan 2006 15:04 MST")
+	passed := make([]string, len(crossed))
+	for i, t := range crossed {
+		passed[i] = fmt.Sprintf("%d%%", t)
+	}
+	payload := events.Notification{
+		ID:          fmt.Sprintf("%s:sla:%d", reportID, threshold),
+		ReportID:    reportID,
+		Title:       fmt.Sprintf("Peringatan SLA %d%%", threshold),
+		Message:     fmt.Sprintf("Laporan \"%s\" telah melewati %s waktu SLA. Tenggat: %s", report.Title, strings.Join(passed, ", "), deadline),
+		Type:        "sla_warning",
+		Status:      report.Status,
+		Category:    report.Category,
+		Departments: reportDepartmentKeys(report),
+		CreatedAt:   time.Now(),
+	}
+	return newOutboxEvent(events.TypeReportUpdated, reportID, "reports", "report.updated", payload)
+}
+
+// escalationNotificationEvent tells the assigned departments' admins that
+// the report was escalated, manually or by the SLA worker.
+func escalationNotificationEvent(report models.Report) (models.OutboxEvent, error) {
+	reportID := report.ID.Hex()
+	message := fmt.Sprintf("Laporan \"%s\" telah dieskalasi", report.Title)
+	if report.EscalatedBy == "SYSTEM_AUTO_SLA" {
+		message = fmt.Sprintf("Laporan \"%s\" dieskalasi otomatis karena melewati tenggat SLA", report.Title)
+	}
+	payload := events.Notification{
+		ID:          reportID + ":escalation",
+		ReportID:    reportID,
+		Title:       "Laporan Dieskalasi",
+		Message:     message,
+		Type:        "escalation",
+		Status:      report.Status,
+		Category:    report.Category,
+		Departments: reportDepartmentKeys(report),
+		CreatedAt:   time.Now(),
+	}
+	return newOutboxEvent(events.TypeReportUpdated, reportID, "reports", "report.updated", payload)
+}
+
+// reportDepartmentKeys lists the report's assigned departments by canonical
+// key, the form admin notifications are addressed with.
+func reportDepartmentKeys(report models.Report) []string {
+	departments := make([]string, 0, len(report.AssignedDepartments))
+	for _, d := range report.AssignedDepartments {
+		if key := departmentKey(d); key != "" && !containsString(departments, key) {
+			departments = append(departments, key)
+		}
+	}
+	return departments
+}
+
+func slaLocation() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Jakarta"); err == nil {
+		return loc
+	}
+	return time.FixedZone("WIB", 7*60*60)
+}
@@ -0,0 +1,710 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"citizen-reporting-system/pkg/response"
+	"citizen-reporting-system/pkg/security"
+	"citizen-reporting-system/pkg/telemetry"
+	"citizen-reporting-system/services/report-service/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	webhookSubscriptionCollection = "webhook_subscriptions"
+	webhookDeliveryCollection     = "webhook_deliveries"
+	webhookLockDuration           = time.Minute
+	webhookDeliveryRetention      = 30 * 24 * time.Hour
+)
+
+var (
+	webhookTimeout      = envDurationOr("WEBHOOK_TIMEOUT", 10*time.Second)
+	webhookMaxAttempts  = envIntOr("WEBHOOK_MAX_ATTEMPTS", 10)
+	webhookRetryBase    = envDurationOr("WEBHOOK_RETRY_BASE", 10*time.Second)
+	webhookRetryMax     = envDurationOr("WEBHOOK_RETRY_MAX", time.Hour)
+	webhookPollInterval = envDurationOr("WEBHOOK_POLL_INTERVAL", 2*time.Second)
+
+	// Subscribers are third parties: never follow their redirects, and only
+	// connect to public addresses, checked after DNS resolution so a name
+	// cannot be pointed inside the network once it passed validation.
+	webhookClient = &http.Client{
+		Transport: telemetry.NewTransport(&http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: 10 * time.Second,
+				Control: security.PublicDialControl,
+			}).DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        50,
+			IdleConnTimeout:     90 * time.Second,
+		}),
+		Timeout: webhookTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+)
+
+func ensureWebhookIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := db.Collection(webhookSubscriptionCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "active", Value: 1}, {Key: "event_types", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(webhookDeliveryCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(webhookDeliveryRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+// publicWebhookReport is everything a subscriber gets to see. Reporter
+// identity, location, description and photos never leave the service.
+func publicWebhookReport(report models.Report) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                   report.ID.Hex(),
+		"title":                report.Title,
+		"category":             report.Category,
+		"subcategory":          report.Subcategory,
+		"region":               report.Region,
+		"priority":             report.Priority,
+		"status":               report.Status,
+		"assigned_departments": report.AssignedDepartments,
+		"is_public":            report.IsPublic,
+		"is_escalated":         report.IsEscalated,
+		"upvotes":              report.Upvotes,
+		"created_at":           report.CreatedAt,
+		"updated_at":           report.UpdatedAt,
+	}
+}
+
+// webhookEventsForUpdate derives the subscriber events implied by a report
+// update from the fields it sets.
+func webhookEventsForUpdate(update bson.M, updated models.Report) []string {
+	set, _ := update["$set"].(bson.M)
+	var events []string
+	if _, ok := set["status"]; ok {
+		events = append(events, models.WebhookEventStatusChanged)
+		if updated.Status == "RESOLVED" {
+			events = append(events, models.WebhookEventResolved)
+		}
+	}
+	if v, ok := set["is_escalated"].(bool); ok && v {
+		events = append(events, models.WebhookEventEscalated)
+	}
+	return events
+}
+
+func webhookMatches(sub models.WebhookSubscription, report models.Report) bool {
+	if len(sub.Categories) > 0 {
+		found := false
+		for _, c := range sub.Categories {
+			if strings.EqualFold(c, report.Category) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(sub.Departments) > 0 {
+		for _, want := range sub.Departments {
+			for _, have := range report.AssignedDepartments {
+				if departmentKey(want) == departmentKey(have) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// enqueueWebhookEvents writes one delivery per matching subscription and
+// event. It is called inside the same transaction as the report change.
+func enqueueWebhookEvents(ctx context.Context, report models.Report, eventTypes ...string) error {
+	// Partners only hear about reports the citizen made public.
+	if len(eventTypes) == 0 || !report.IsPublic {
+		return nil
+	}
+
+	cursor, err := db.Collection(webhookSubscriptionCollection).Find(ctx, bson.M{
+		"active":      true,
+		"event_types": bson.M{"$in": eventTypes},
+	})
+	if err != nil {
+		return err
+	}
+	var subs []models.WebhookSubscription
+	if err := cursor.All(ctx, &subs); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	data := publicWebhookReport(report)
+	var docs []interface{}
+	for _, eventType := range eventTypes {
+		eventID := primitive.NewObjectID().Hex()
+		body, err := json.Marshal(map[string]interface{}{
+			"id":          eventID,
+			"type":        eventType,
+			"occurred_at": now.UTC(),
+			"data":        data,
+		})
+		if err != nil {
+			return err
+		}
+
+		traceContext := map[string]string{}
+		telemetry.Inject(ctx, traceContext)
+
+		for _, sub := range subs {
+			if !containsFold(sub.EventTypes, eventType) || !webhookMatches(sub, report) {
+				continue
+			}
+			docs = append(docs, models.WebhookDelivery{
+				ID:             primitive.NewObjectID(),
+				SubscriptionID: sub.ID,
+				EventID:        eventID,
+				EventType:      eventType,
+				ReportID:       report.ID.Hex(),
+				Payload:        string(body),
+				Status:         models.WebhookDeliveryPending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+				TraceContext:   traceContext,
+			})
+		}
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	_, err = db.Collection(webhookDeliveryCollection).InsertMany(ctx, docs)
+	return err
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func webhookBackoff(attempts int) time.Duration {
+	d := webhookRetryBase
+	for i := 1; i < attempts && d < webhookRetryMax; i++ {
+		d *= 2
+	}
+	if d > webhookRetryMax {
+		d = webhookRetryMax
+	}
+	return d
+}
+
+// signWebhook uses the same scheme as the dispatcher's webhook connector:
+// hex HMAC-SHA256 over "<timestamp>.<body>".
+func signWebhook(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func startWebhookWorker() {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	log.Println("[INFO] Webhook delivery worker started")
+
+	for range ticker.C {
+		for i := 0; i < 50; i++ {
+			if !deliverNextWebhook() {
+				break
+			}
+		}
+	}
+}
+
+func deliverNextWebhook() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout+15*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var d models.WebhookDelivery
+	err := db.Collection(webhookDeliveryCollection).FindOneAndUpdate(ctx,
+		bson.M{
+			"status":          models.WebhookDeliveryPending,
+			"next_attempt_at": bson.M{"$lte": now},
+			"$or": []bson.M{
+				{"locked_until": bson.M{"$exists": false}},
+				{"locked_until": bson.M{"$lt": now}},
+			},
+		},
+		bson.M{"$set": bson.M{"locked_until": now.Add(webhookLockDuration), "locked_by": outboxInstanceID}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&d)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("[ERROR] Webhooks: failed to claim delivery: %v", err)
+		}
+		return false
+	}
+
+	var sub models.WebhookSubscription
+	if err := db.Collection(webhookSubscriptionCollection).FindOne(ctx, bson.M{"_id": d.SubscriptionID}).Decode(&sub); err != nil || !sub.Active {
+		finishWebhook(ctx, d, models.WebhookDeliveryFailed, models.WebhookAttempt{At: now, Error: "subscription deleted or inactive"})
+		return true
+	}
+
+	ctx, span := telemetry.Tracer().Start(telemetry.Extract(ctx, d.TraceContext), "webhook.deliver",
+		trace.WithAttributes(
+			attribute.String("webhook.delivery_id", d.ID.Hex()),
+			attribute.String("webhook.event_type", d.EventType),
+			attribute.Int("webhook.attempt", d.Attempts+1),
+		),
+	)
+	defer span.End()
+
+	attempt := sendWebhook(ctx, sub, d)
+	if attempt.Error != "" {
+		span.SetStatus(codes.Error, attempt.Error)
+	}
+	switch {
+	case attempt.Error == "":
+		finishWebhook(ctx, d, models.WebhookDeliverySuccess, attempt)
+	case d.Attempts+1 >= webhookMaxAttempts:
+		finishWebhook(ctx, d, models.WebhookDeliveryFailed, attempt)
+		log.Printf("[ERROR] Webhook %s to %s failed permanently: %s", d.ID.Hex(), sub.URL, attempt.Error)
+	default:
+		next := time.Now().Add(webhookBackoff(d.Attempts + 1))
+		_, _ = db.Collection(webhookDeliveryCollection).UpdateOne(ctx, bson.M{"_id": d.ID}, bson.M{
+			"$set":   bson.M{"attempts": d.Attempts + 1, "next_attempt_at": next},
+			"$push":  bson.M{"attempt_log": attempt},
+			"$unset": bson.M{"locked_until": "", "locked_by": ""},
+		})
+		log.Printf("[WARN] Webhook %s to %s failed (attempt %d), retry at %s: %s",
+			d.ID.Hex(), sub.URL, d.Attempts+1, next.Format(time.RFC3339), attempt.Error)
+	}
+	return true
+}
+
+func sendWebhook(ctx context.Context, sub models.WebhookSubscription, d models.WebhookDelivery) models.WebhookAttempt {
+	start := time.Now()
+	attempt := models.WebhookAttempt{At: start}
+
+	// Subscriptions saved before the URL rules still go through them.
+	if _, err := security.ValidatePublicURL(sub.URL); err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	body := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		attempt.DurationMs = time.Since(start).Milliseconds()
+		return attempt
+	}
+	ts := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "citizen-reporting-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Delivery", d.ID.Hex())
+	req.Header.Set("X-Webhook-Timestamp", ts)
+	req.Header.Set("X-Webhook-Signature", signWebhook(sub.Secret, ts, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		attempt.DurationMs = time.Since(start).Milliseconds()
+		return attempt
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("subscriber returned %d", resp.StatusCode)
+	}
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	return attempt
+}
+
+func finishWebhook(ctx context.Context, d models.WebhookDelivery, status string, attempt models.WebhookAttempt) {
+	set := bson.M{"status": status, "attempts": d.Attempts + 1}
+	if status == models.WebhookDeliverySuccess {
+		set["delivered_at"] = time.Now()
+	}
+	_, err := db.Collection(webhookDeliveryCollection).UpdateOne(ctx, bson.M{"_id": d.ID}, bson.M{
+		"$set":   set,
+		"$push":  bson.M{"attempt_log": attempt},
+		"$unset": bson.M{"locked_until": "", "locked_by": ""},
+	})
+	if err != nil {
+		log.Printf("[ERROR] Webhooks: failed to record delivery %s: %v", d.ID.Hex(), err)
+	}
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+type webhookInput struct {
+	Name        string   `json:"name"`
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	Departments []string `json:"departments"`
+	Categories  []string `json:"categories"`
+	Active      *bool    `json:"active"`
+}
+
+func (in *webhookInput) validate() error {
+	u, err := security.ValidatePublicURL(in.URL)
+	if err != nil {
+		return err
+	}
+	in.URL = u.String()
+
+	if len(in.EventTypes) == 0 {
+		in.EventTypes = append([]string(nil), models.WebhookEventTypes...)
+	}
+	for i, t := range in.EventTypes {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !containsFold(models.WebhookEventTypes, t) {
+			return fmt.Errorf("unknown event type %q (allowed: %s)", t, strings.Join(models.WebhookEventTypes, ", "))
+		}
+		in.EventTypes[i] = t
+	}
+	return nil
+}
+
+// adminWebhooksHandler manages partner webhook subscriptions:
+//
+//	GET    /api/reports/admin/webhooks                           list
+//	POST   /api/reports/admin/webhooks                           create (returns secret once)
+//	GET    /api/reports/admin/webhooks/{id}                      show
+//	PUT    /api/reports/admin/webhooks/{id}                      update
+//	DELETE /api/reports/admin/webhooks/{id}                      delete
+//	POST   /api/reports/admin/webhooks/{id}/rotate-secret        new secret
+//	GET    /api/reports/admin/webhooks/{id}/deliveries           delivery log
+//	POST   /api/reports/admin/webhooks/deliveries/{id}/redeliver queue again
+func adminWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/reports/admin/webhooks"), "/")
+	parts := strings.Split(rest, "/")
+
+	switch {
+	case rest == "" && r.Method == http.MethodGet:
+		listWebhooks(w, r)
+	case rest == "" && r.Method == http.MethodPost:
+		createWebhook(w, r)
+	case len(parts) == 3 && parts[0] == "deliveries" && parts[2] == "redeliver" && r.Method == http.MethodPost:
+		redeliverWebhook(w, r, parts[1])
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		getWebhook(w, r, parts[0])
+	case len(parts) == 1 && r.Method == http.MethodPut:
+		updateWebhook(w, r, parts[0])
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		deleteWebhook(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "rotate-secret" && r.Method == http.MethodPost:
+		rotateWebhookSecret(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "deliveries" && r.Method == http.MethodGet:
+		listWebhookDeliveries(w, r, parts[0])
+	default:
+		response.Error(w, http.StatusNotFound, "Not found", "")
+	}
+}
+
+func listWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := db.Collection(webhookSubscriptionCollection).Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch webhooks", err.Error())
+		return
+	}
+	subs := make([]models.WebhookSubscription, 0)
+	if err := cursor.All(ctx, &subs); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to decode webhooks", err.Error())
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Webhooks fetched successfully", subs)
+}
+
+func createWebhook(w http.ResponseWriter, r *http.Request) {
+	var input webhookInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if err := input.validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid webhook", err.Error())
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to generate secret", err.Error())
+		return
+	}
+
+	actorID, _, _ := auditActor(r)
+	now := time.Now()
+	sub := models.WebhookSubscription{
+		ID:          primitive.NewObjectID(),
+		Name:        strings.TrimSpace(input.Name),
+		URL:         input.URL,
+		Secret:      secret,
+		EventTypes:  input.EventTypes,
+		Departments: input.Departments,
+		Categories:  input.Categories,
+		Active:      input.Active == nil || *input.Active,
+		CreatedBy:   actorID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := db.Collection(webhookSubscriptionCollection).InsertOne(ctx, sub); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to create webhook", err.Error())
+		return
+	}
+
+	recordAudit(r, models.AuditActionWebhook, nil, map[string]string{"op": "create", "subscription_id": sub.ID.Hex(), "url": sub.URL})
+	log.Printf("[OK] Webhook subscription created - ID: %s, URL: %s", sub.ID.Hex(), sub.URL)
+
+	response.Success(w, http.StatusCreated, "Webhook created; store the secret now, it is not shown again", map[string]interface{}{
+		"subscription": sub,
+		"secret":       secret,
+	})
+}
+
+func webhookByID(w http.ResponseWriter, ctx context.Context, id string) (models.WebhookSubscription, bool) {
+	var sub models.WebhookSubscription
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return sub, false
+	}
+	err = db.Collection(webhookSubscriptionCollection).FindOne(ctx, bson.M{"_id": objID}).Decode(&sub)
+	if err == mongo.ErrNoDocuments {
+		response.Error(w, http.StatusNotFound, "Webhook not found", "")
+		return sub, false
+	}
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch webhook", err.Error())
+		return sub, false
+	}
+	return sub, true
+}
+
+func getWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, ok := webhookByID(w, ctx, id)
+	if !ok {
+		return
+	}
+	response.Success(w, http.StatusOK, "Webhook fetched successfully", sub)
+}
+
+func updateWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	var input webhookInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request payload", err.Error())
+		return
+	}
+	if err := input.validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid webhook", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, ok := webhookByID(w, ctx, id)
+	if !ok {
+		return
+	}
+
+	set := bson.M{
+		"name":        strings.TrimSpace(input.Name),
+		"url":         input.URL,
+		"event_types": input.EventTypes,
+		"departments": input.Departments,
+		"categories":  input.Categories,
+		"updated_at":  time.Now(),
+	}
+	if input.Active != nil {
+		set["active"] = *input.Active
+	}
+
+	var updated models.WebhookSubscription
+	err := db.Collection(webhookSubscriptionCollection).FindOneAndUpdate(ctx, bson.M{"_id": sub.ID}, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&updated)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to update webhook", err.Error())
+		return
+	}
+
+	recordAudit(r, models.AuditActionWebhook, nil, map[string]string{"op": "update", "subscription_id": id, "url": updated.URL})
+	response.Success(w, http.StatusOK, "Webhook updated successfully", updated)
+}
+
+func deleteWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, ok := webhookByID(w, ctx, id)
+	if !ok {
+		return
+	}
+	if _, err := db.Collection(webhookSubscriptionCollection).DeleteOne(ctx, bson.M{"_id": sub.ID}); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to delete webhook", err.Error())
+		return
+	}
+
+	recordAudit(r, models.AuditActionWebhook, nil, map[string]string{"op": "delete", "subscription_id": id, "url": sub.URL})
+	response.Success(w, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+func rotateWebhookSecret(w http.ResponseWriter, r *http.Request, id string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, ok := webhookByID(w, ctx, id)
+	if !ok {
+		return
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to generate secret", err.Error())
+		return
+	}
+	_, err = db.Collection(webhookSubscriptionCollection).UpdateOne(ctx, bson.M{"_id": sub.ID},
+		bson.M{"$set": bson.M{"secret": secret, "updated_at": time.Now()}})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to rotate secret", err.Error())
+		return
+	}
+
+	recordAudit(r, models.AuditActionWebhook, nil, map[string]string{"op": "rotate_secret", "subscription_id": id})
+	response.Success(w, http.StatusOK, "Webhook secret rotated", map[string]string{"secret": secret})
+}
+
+func listWebhookDeliveries(w http.ResponseWriter, r *http.Request, id string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	sub, ok := webhookByID(w, ctx, id)
+	if !ok {
+		return
+	}
+
+	filter := bson.M{"subscription_id": sub.ID}
+	if status := strings.ToUpper(r.URL.Query().Get("status")); status != "" {
+		filter["status"] = status
+	}
+	if eventType := r.URL.Query().Get("event_type"); eventType != "" {
+		filter["event_type"] = eventType
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	cursor, err := db.Collection(webhookDeliveryCollection).Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit)))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch deliveries", err.Error())
+		return
+	}
+	deliveries := make([]models.WebhookDelivery, 0)
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to decode deliveries", err.Error())
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Deliveries fetched successfully", deliveries)
+}
+
+// redeliverWebhook queues a delivery again with a fresh attempt budget. The
+// payload and event ID are unchanged so subscribers can deduplicate.
+func redeliverWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid delivery ID", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	actorID, _, _ := auditActor(r)
+	res, err := db.Collection(webhookDeliveryCollection).UpdateOne(ctx,
+		bson.M{"_id": objID, "status": bson.M{"$ne": models.WebhookDeliveryPending}},
+		bson.M{
+			"$set": bson.M{
+				"status":          models.WebhookDeliveryPending,
+				"attempts":        0,
+				"next_attempt_at": time.Now(),
+				"redelivered_by":  actorID,
+			},
+			"$unset": bson.M{"delivered_at": ""},
+		})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to redeliver", err.Error())
+		return
+	}
+	if res.MatchedCount == 0 {
+		response.Error(w, http.StatusNotFound, "Delivery not found or already pending", "")
+		return
+	}
+
+	recordAudit(r, models.AuditActionWebhook, nil, map[string]string{"op": "redeliver", "delivery_id": id})
+	response.Success(w, http.StatusAccepted, "Delivery queued", nil)
+}